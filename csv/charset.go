@@ -0,0 +1,367 @@
+package csv
+
+import (
+	"io"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"unicode/utf16"
+)
+
+const (
+	charset_windows1252	= "Windows-1252"
+	charset_iso8859_15	= "ISO-8859-15"
+	charset_cp865		= "CP865"
+	charset_mac_roman	= "MacRoman"
+	
+	//	Bytes sampled for UTF16 detection without BOM
+	utf16_sample = 4096
+	
+	//	Letters and symbols expected in Danish and European exports
+	common_letters	= "æøåÆØÅéèëäöüÄÖÜß"
+	common_symbols	= "€£§°½«»–—…•“”„"
+)
+
+var (
+	//	Single-byte candidates in order of preference when scores are equal
+	charset_candidates = []string{
+		charset_windows1252,
+		charset_iso8859_15,
+		charset_cp865,
+		charset_mac_roman,
+	}
+	
+	charset_tables = map[string]*[128]rune{
+		charset_windows1252:	&table_windows1252,
+		charset_iso8859_15:		&table_iso8859_15,
+		charset_cp865:			&table_cp865,
+		charset_mac_roman:		&table_mac_roman,
+	}
+	
+	charset_names = map[string]string{
+		"utf-8":			charset_utf8,
+		"utf8":				charset_utf8,
+		"utf-16le":			charset_utf16le,
+		"utf16le":			charset_utf16le,
+		"utf-16be":			charset_utf16be,
+		"utf16be":			charset_utf16be,
+		"iso-8859-1":		charset_latin1,
+		"latin1":			charset_latin1,
+		"windows-1252":		charset_windows1252,
+		"cp1252":			charset_windows1252,
+		"iso-8859-15":		charset_iso8859_15,
+		"latin9":			charset_iso8859_15,
+		"cp865":			charset_cp865,
+		"ibm865":			charset_cp865,
+		"macintosh":		charset_mac_roman,
+		"mac-roman":		charset_mac_roman,
+		"macroman":			charset_mac_roman,
+	}
+	
+	//	Windows-1252 (undefined bytes map to C1 controls)
+	table_windows1252 = [128]rune{
+		0x20AC,	0x0081,	0x201A,	0x0192,	0x201E,	0x2026,	0x2020,	0x2021,
+		0x02C6,	0x2030,	0x0160,	0x2039,	0x0152,	0x008D,	0x017D,	0x008F,
+		0x0090,	0x2018,	0x2019,	0x201C,	0x201D,	0x2022,	0x2013,	0x2014,
+		0x02DC,	0x2122,	0x0161,	0x203A,	0x0153,	0x009D,	0x017E,	0x0178,
+		0x00A0,	0x00A1,	0x00A2,	0x00A3,	0x00A4,	0x00A5,	0x00A6,	0x00A7,
+		0x00A8,	0x00A9,	0x00AA,	0x00AB,	0x00AC,	0x00AD,	0x00AE,	0x00AF,
+		0x00B0,	0x00B1,	0x00B2,	0x00B3,	0x00B4,	0x00B5,	0x00B6,	0x00B7,
+		0x00B8,	0x00B9,	0x00BA,	0x00BB,	0x00BC,	0x00BD,	0x00BE,	0x00BF,
+		0x00C0,	0x00C1,	0x00C2,	0x00C3,	0x00C4,	0x00C5,	0x00C6,	0x00C7,
+		0x00C8,	0x00C9,	0x00CA,	0x00CB,	0x00CC,	0x00CD,	0x00CE,	0x00CF,
+		0x00D0,	0x00D1,	0x00D2,	0x00D3,	0x00D4,	0x00D5,	0x00D6,	0x00D7,
+		0x00D8,	0x00D9,	0x00DA,	0x00DB,	0x00DC,	0x00DD,	0x00DE,	0x00DF,
+		0x00E0,	0x00E1,	0x00E2,	0x00E3,	0x00E4,	0x00E5,	0x00E6,	0x00E7,
+		0x00E8,	0x00E9,	0x00EA,	0x00EB,	0x00EC,	0x00ED,	0x00EE,	0x00EF,
+		0x00F0,	0x00F1,	0x00F2,	0x00F3,	0x00F4,	0x00F5,	0x00F6,	0x00F7,
+		0x00F8,	0x00F9,	0x00FA,	0x00FB,	0x00FC,	0x00FD,	0x00FE,	0x00FF,
+	}
+	
+	//	ISO-8859-15 (Latin-9)
+	table_iso8859_15 = [128]rune{
+		0x0080,	0x0081,	0x0082,	0x0083,	0x0084,	0x0085,	0x0086,	0x0087,
+		0x0088,	0x0089,	0x008A,	0x008B,	0x008C,	0x008D,	0x008E,	0x008F,
+		0x0090,	0x0091,	0x0092,	0x0093,	0x0094,	0x0095,	0x0096,	0x0097,
+		0x0098,	0x0099,	0x009A,	0x009B,	0x009C,	0x009D,	0x009E,	0x009F,
+		0x00A0,	0x00A1,	0x00A2,	0x00A3,	0x20AC,	0x00A5,	0x0160,	0x00A7,
+		0x0161,	0x00A9,	0x00AA,	0x00AB,	0x00AC,	0x00AD,	0x00AE,	0x00AF,
+		0x00B0,	0x00B1,	0x00B2,	0x00B3,	0x017D,	0x00B5,	0x00B6,	0x00B7,
+		0x017E,	0x00B9,	0x00BA,	0x00BB,	0x0152,	0x0153,	0x0178,	0x00BF,
+		0x00C0,	0x00C1,	0x00C2,	0x00C3,	0x00C4,	0x00C5,	0x00C6,	0x00C7,
+		0x00C8,	0x00C9,	0x00CA,	0x00CB,	0x00CC,	0x00CD,	0x00CE,	0x00CF,
+		0x00D0,	0x00D1,	0x00D2,	0x00D3,	0x00D4,	0x00D5,	0x00D6,	0x00D7,
+		0x00D8,	0x00D9,	0x00DA,	0x00DB,	0x00DC,	0x00DD,	0x00DE,	0x00DF,
+		0x00E0,	0x00E1,	0x00E2,	0x00E3,	0x00E4,	0x00E5,	0x00E6,	0x00E7,
+		0x00E8,	0x00E9,	0x00EA,	0x00EB,	0x00EC,	0x00ED,	0x00EE,	0x00EF,
+		0x00F0,	0x00F1,	0x00F2,	0x00F3,	0x00F4,	0x00F5,	0x00F6,	0x00F7,
+		0x00F8,	0x00F9,	0x00FA,	0x00FB,	0x00FC,	0x00FD,	0x00FE,	0x00FF,
+	}
+	
+	//	CP865 (DOS Nordic)
+	table_cp865 = [128]rune{
+		0x00C7,	0x00FC,	0x00E9,	0x00E2,	0x00E4,	0x00E0,	0x00E5,	0x00E7,
+		0x00EA,	0x00EB,	0x00E8,	0x00EF,	0x00EE,	0x00EC,	0x00C4,	0x00C5,
+		0x00C9,	0x00E6,	0x00C6,	0x00F4,	0x00F6,	0x00F2,	0x00FB,	0x00F9,
+		0x00FF,	0x00D6,	0x00DC,	0x00F8,	0x00A3,	0x00D8,	0x20A7,	0x0192,
+		0x00E1,	0x00ED,	0x00F3,	0x00FA,	0x00F1,	0x00D1,	0x00AA,	0x00BA,
+		0x00BF,	0x2310,	0x00AC,	0x00BD,	0x00BC,	0x00A1,	0x00AB,	0x00A4,
+		0x2591,	0x2592,	0x2593,	0x2502,	0x2524,	0x2561,	0x2562,	0x2556,
+		0x2555,	0x2563,	0x2551,	0x2557,	0x255D,	0x255C,	0x255B,	0x2510,
+		0x2514,	0x2534,	0x252C,	0x251C,	0x2500,	0x253C,	0x255E,	0x255F,
+		0x255A,	0x2554,	0x2569,	0x2566,	0x2560,	0x2550,	0x256C,	0x2567,
+		0x2568,	0x2564,	0x2565,	0x2559,	0x2558,	0x2552,	0x2553,	0x256B,
+		0x256A,	0x2518,	0x250C,	0x2588,	0x2584,	0x258C,	0x2590,	0x2580,
+		0x03B1,	0x00DF,	0x0393,	0x03C0,	0x03A3,	0x03C3,	0x00B5,	0x03C4,
+		0x03A6,	0x0398,	0x03A9,	0x03B4,	0x221E,	0x03C6,	0x03B5,	0x2229,
+		0x2261,	0x00B1,	0x2265,	0x2264,	0x2320,	0x2321,	0x00F7,	0x2248,
+		0x00B0,	0x2219,	0x00B7,	0x221A,	0x207F,	0x00B2,	0x25A0,	0x00A0,
+	}
+	
+	//	Mac Roman
+	table_mac_roman = [128]rune{
+		0x00C4,	0x00C5,	0x00C7,	0x00C9,	0x00D1,	0x00D6,	0x00DC,	0x00E1,
+		0x00E0,	0x00E2,	0x00E4,	0x00E3,	0x00E5,	0x00E7,	0x00E9,	0x00E8,
+		0x00EA,	0x00EB,	0x00ED,	0x00EC,	0x00EE,	0x00EF,	0x00F1,	0x00F3,
+		0x00F2,	0x00F4,	0x00F6,	0x00F5,	0x00FA,	0x00F9,	0x00FB,	0x00FC,
+		0x2020,	0x00B0,	0x00A2,	0x00A3,	0x00A7,	0x2022,	0x00B6,	0x00DF,
+		0x00AE,	0x00A9,	0x2122,	0x00B4,	0x00A8,	0x2260,	0x00C6,	0x00D8,
+		0x221E,	0x00B1,	0x2264,	0x2265,	0x00A5,	0x00B5,	0x2202,	0x2211,
+		0x220F,	0x03C0,	0x222B,	0x00AA,	0x00BA,	0x03A9,	0x00E6,	0x00F8,
+		0x00BF,	0x00A1,	0x00AC,	0x221A,	0x0192,	0x2248,	0x2206,	0x00AB,
+		0x00BB,	0x2026,	0x00A0,	0x00C0,	0x00C3,	0x00D5,	0x0152,	0x0153,
+		0x2013,	0x2014,	0x201C,	0x201D,	0x2018,	0x2019,	0x00F7,	0x25CA,
+		0x00FF,	0x0178,	0x2044,	0x20AC,	0x2039,	0x203A,	0xFB01,	0xFB02,
+		0x2021,	0x00B7,	0x201A,	0x201E,	0x2030,	0x00C2,	0x00CA,	0x00C1,
+		0x00CB,	0x00C8,	0x00CD,	0x00CE,	0x00CF,	0x00CC,	0x00D3,	0x00D4,
+		0xF8FF,	0x00D2,	0x00DA,	0x00DB,	0x00D9,	0x0131,	0x02C6,	0x02DC,
+		0x00AF,	0x02D8,	0x02D9,	0x02DA,	0x00B8,	0x02DD,	0x02DB,	0x02C7,
+	}
+)
+
+//	Transcode UTF16 stream to UTF8
+type utf16_reader struct {
+	src			io.Reader
+	big_endian	bool
+	in			[]byte
+	out			[]byte
+	err			error
+}
+
+func newUtf16_reader(src io.Reader, big_endian bool) *utf16_reader {
+	return &utf16_reader{
+		src:		src,
+		big_endian:	big_endian,
+	}
+}
+
+func (u *utf16_reader) Read(p []byte) (int, error){
+	for len(u.out) == 0 {
+		if u.err != nil {
+			return 0, u.err
+		}
+		
+		buf := make([]byte, 4096)
+		n, err := u.src.Read(buf)
+		u.in	= append(u.in, buf[:n]...)
+		u.err	= err
+		
+		out, rest := decode_utf16(u.in, u.big_endian)
+		u.out	= out
+		u.in	= u.in[len(u.in)-rest:]
+	}
+	
+	n := copy(p, u.out)
+	u.out = u.out[n:]
+	return n, nil
+}
+
+//	Detect UTF16 without BOM by the share of NUL bytes at even and odd positions
+func detect_utf16(b []byte) string {
+	b = b[:min(len(b), utf16_sample) &^ 1]
+	pairs := len(b) / 2
+	if pairs == 0 {
+		return ""
+	}
+	
+	var even, odd int
+	for i := 0; i < len(b); i += 2 {
+		if b[i] == 0 {
+			even++
+		}
+		if b[i+1] == 0 {
+			odd++
+		}
+	}
+	
+	switch {
+	case odd * 10 > pairs * 3 && even * 10 < pairs:
+		return charset_utf16le
+	case even * 10 > pairs * 3 && odd * 10 < pairs:
+		return charset_utf16be
+	}
+	return ""
+}
+
+//	Decode UTF16 to UTF8 and return the length of an incomplete tail
+func decode_utf16(b []byte, big_endian bool) ([]byte, int){
+	out := make([]byte, 0, len(b) + len(b) / 2)
+	i := 0
+	for ; i + 1 < len(b); i += 2 {
+		u := utf16_unit(b[i:], big_endian)
+		if !utf16.IsSurrogate(rune(u)) {
+			out = utf8.AppendRune(out, rune(u))
+			continue
+		}
+		
+		//	Surrogate pair
+		if i + 3 >= len(b) {
+			break
+		}
+		r := utf16.DecodeRune(rune(u), rune(utf16_unit(b[i+2:], big_endian)))
+		out = utf8.AppendRune(out, r)
+		if r != utf8.RuneError {
+			i += 2
+		}
+	}
+	return out, len(b) - i
+}
+
+func utf16_unit(b []byte, big_endian bool) uint16 {
+	if big_endian {
+		return uint16(b[0]) << 8 | uint16(b[1])
+	}
+	return uint16(b[1]) << 8 | uint16(b[0])
+}
+
+//	Detect single-byte charset by scoring the decoded chars in context
+func detect_charset(b []byte) string {
+	best		:= charset_candidates[0]
+	best_score	:= 0
+	for i, charset := range charset_candidates {
+		score := charset_score(b, charset_tables[charset])
+		if i == 0 || score > best_score {
+			best		= charset
+			best_score	= score
+		}
+	}
+	return best
+}
+
+func charset_score(b []byte, table *[128]rune) int {
+	decode := func(i int) rune {
+		if i < 0 || i >= len(b) {
+			return ' '
+		}
+		if b[i] < 0x80 {
+			return rune(b[i])
+		}
+		return table[b[i]-0x80]
+	}
+	
+	score := 0
+	for i, c := range b {
+		if c >= 0x80 {
+			score += rune_score(decode(i), decode(i-1), decode(i+1))
+		}
+	}
+	return score
+}
+
+func rune_score(r, prev, next rune) int {
+	switch {
+	//	C1 control chars never appear in text
+	case r >= 0x80 && r <= 0x9F:
+		return -5
+	case unicode.IsLetter(r):
+		//	Upper case after lower case is unlikely
+		if unicode.IsUpper(r) && unicode.IsLower(prev) {
+			return 0
+		}
+		if unicode.IsLetter(prev) || unicode.IsLetter(next) {
+			if strings.ContainsRune(common_letters, r) {
+				return 3
+			}
+			return 2
+		}
+		return 1
+	case r == '’' || r == '‘':
+		return 1
+	//	Symbols inside words are unlikely
+	case unicode.IsLetter(prev) && unicode.IsLetter(next):
+		return -2
+	case strings.ContainsRune(common_symbols, r):
+		return 1
+	//	Box drawing and block elements
+	case r >= 0x2500 && r <= 0x25FF:
+		return -1
+	}
+	return 0
+}
+
+//	Decode single-byte charset to UTF8 (Latin1 has no table)
+func decode_charset(b []byte, charset string) string {
+	table := charset_tables[charset]
+	out := make([]byte, 0, len(b) + len(b) / 2)
+	for _, c := range b {
+		switch {
+		case c < 0x80:
+			out = append(out, c)
+		case table != nil:
+			out = utf8.AppendRune(out, table[c-0x80])
+		default:
+			out = utf8.AppendRune(out, rune(c))
+		}
+	}
+	return string(out)
+}
+
+//	Encode UTF8 string to single-byte charset
+func encode_charset(s, charset string) ([]byte, error){
+	var reverse map[rune]byte
+	if table := charset_tables[charset]; table != nil {
+		reverse = make(map[rune]byte, len(table))
+		for i, c := range table {
+			reverse[c] = byte(i + 0x80)
+		}
+	}
+	
+	out := make([]byte, 0, len(s))
+	for _, c := range s {
+		switch {
+		case c < 0x80:
+			out = append(out, byte(c))
+		case reverse != nil:
+			b, ok := reverse[c]
+			if !ok {
+				return nil, fmt.Errorf("Character %q can not be encoded as %s", c, charset)
+			}
+			out = append(out, b)
+		case c <= 0xFF:
+			out = append(out, byte(c))
+		default:
+			return nil, fmt.Errorf("Character %q can not be encoded as %s", c, charset)
+		}
+	}
+	return out, nil
+}
+
+//	Strip BOM matching charset
+func strip_bom(b []byte, charset string) []byte {
+	var bom string
+	switch charset {
+	case charset_utf8:
+		bom = BOM_UTF8
+	case charset_utf16le:
+		bom = BOM_UTF16LE
+	case charset_utf16be:
+		bom = BOM_UTF16BE
+	default:
+		return b
+	}
+	if strings.HasPrefix(string(b[:min(len(b), len(bom))]), bom) {
+		return b[len(bom):]
+	}
+	return b
+}
\ No newline at end of file
@@ -0,0 +1,401 @@
+package csv
+
+import (
+	"io"
+	"fmt"
+	"iter"
+	"time"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	type_time			= reflect.TypeFor[time.Time]()
+	type_unmarshaler	= reflect.TypeFor[Unmarshaler]()
+)
+
+type (
+	//	Custom conversion of a cell value to a field
+	Unmarshaler interface {
+		Unmarshal_csv(value string) error
+	}
+	
+	//	Conversion errors of all rows
+	Decode_error struct {
+		Violations	[]Violation
+	}
+	
+	decoder struct {
+		fields	[]decode_field
+	}
+	
+	//	Field tag: csv:"Name,alias=Other,required,decimal=.,layout=02-01-2006,decimal_string"
+	decode_field struct {
+		index			[]int
+		name			string
+		aliases			[]string
+		required		bool
+		decimal			rune
+		layout			string
+		decimal_string	bool
+		col				int
+	}
+)
+
+func (e *Decode_error) Error() string {
+	v := e.Violations[0]
+	if v.Line < 0 {
+		return fmt.Sprintf("%s: %s", v.Message, v.Column)
+	}
+	msg := fmt.Sprintf("%s in line %d column %s", v.Message, v.Line, v.Column)
+	if len(e.Violations) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Violations) - 1)
+	}
+	return msg
+}
+
+//	Decode rows into pointer to slice of structs (rows with conversion errors are kept and reported in a *Decode_error)
+func (t Table) Decode(v any) error {
+	dst := reflect.ValueOf(v)
+	if dst.Kind() != reflect.Pointer || dst.Elem().Kind() != reflect.Slice {
+		return new_error(ErrDecode, "Decode target must be a pointer to a slice of structs", nil)
+	}
+	slice	:= dst.Elem()
+	elem	:= slice.Type().Elem()
+	typ		:= elem
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	
+	d, err := new_decoder(typ, t.Header)
+	if err != nil {
+		return err
+	}
+	d.infer_decimals(t)
+	
+	violations := d.missing()
+	if len(violations) != 0 {
+		return &Decode_error{violations}
+	}
+	for _, row := range t.Rows {
+		item := reflect.New(typ)
+		violations = append(violations, d.decode(row, item.Elem())...)
+		if elem.Kind() == reflect.Pointer {
+			slice = reflect.Append(slice, item)
+		} else {
+			slice = reflect.Append(slice, item.Elem())
+		}
+	}
+	dst.Elem().Set(slice)
+	
+	if len(violations) != 0 {
+		return &Decode_error{violations}
+	}
+	return nil
+}
+
+//	Stream rows decoded into structs (rows with conversion errors are yielded with a *Decode_error)
+//	Decimal separators are inferred from the first rows which are held until the decoder is ready
+func Decode_rows[T any](r *Reader, src io.Reader) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool){
+		var (
+			d		*decoder
+			sample	Rows
+		)
+		decode := func(row Row) bool {
+			var (
+				v	T
+				err	error
+			)
+			if violations := d.decode(row, reflect.ValueOf(&v).Elem()); len(violations) != 0 {
+				err = &Decode_error{violations}
+			}
+			return yield(v, err)
+		}
+		//	Create decoder from the sampled rows and decode them
+		start := func() bool {
+			var (
+				v	T
+				err	error
+			)
+			if d, err = new_decoder(reflect.TypeFor[T](), r.Header()); err != nil {
+				yield(v, err)
+				return false
+			}
+			if violations := d.missing(); len(violations) != 0 {
+				yield(v, &Decode_error{violations})
+				return false
+			}
+			d.infer_decimals(Table{Rows: sample})
+			for _, row := range sample {
+				if !decode(row) {
+					return false
+				}
+			}
+			sample = nil
+			return true
+		}
+		
+		for row, err := range r.Rows(src) {
+			if err != nil {
+				if d == nil && len(sample) != 0 && !start() {
+					return
+				}
+				var v T
+				yield(v, err)
+				return
+			}
+			
+			if d == nil {
+				sample = append(sample, row)
+				if len(sample) < sniff_records || start() {
+					continue
+				}
+				return
+			}
+			if !decode(row) {
+				return
+			}
+		}
+		if d == nil && len(sample) != 0 {
+			start()
+		}
+	}
+}
+
+func new_decoder(typ reflect.Type, header Header) (*decoder, error){
+	if typ.Kind() != reflect.Struct {
+		return nil, new_error(ErrDecode, "Decode target must be a pointer to a slice of structs", nil)
+	}
+	
+	d := &decoder{}
+	for _, sf := range reflect.VisibleFields(typ) {
+		if !sf.IsExported() || sf.Anonymous || !settable(typ, sf.Index) {
+			continue
+		}
+		tag := sf.Tag.Get("csv")
+		if tag == "-" {
+			continue
+		}
+		
+		f := decode_field{
+			index:	sf.Index,
+			name:	sf.Name,
+			col:	-1,
+		}
+		opts := strings.Split(tag, ",")
+		if opts[0] != "" {
+			f.name = opts[0]
+		}
+		for _, opt := range opts[1:] {
+			key, value, _ := strings.Cut(opt, "=")
+			switch key {
+			case "alias":
+				f.aliases = append(f.aliases, value)
+			case "required":
+				f.required = true
+			case "decimal":
+				if value != "," && value != "." {
+					return nil, new_error(ErrDecode, "Invalid decimal separator in tag: "+sf.Name, nil)
+				}
+				f.decimal = rune(value[0])
+			case "layout":
+				f.layout = value
+			case "decimal_string":
+				f.decimal_string = true
+			}
+		}
+		if !decodable(sf.Type) {
+			return nil, new_error(ErrDecode, fmt.Sprintf("Unsupported field type: %s %s", sf.Name, sf.Type), nil)
+		}
+		
+		for _, name := range append([]string{f.name}, f.aliases...) {
+			if f.col = header_index(header, name); f.col != -1 {
+				break
+			}
+		}
+		d.fields = append(d.fields, f)
+	}
+	return d, nil
+}
+
+//	Fields promoted through an unexported embedded pointer can not be allocated
+func settable(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		sf := t.Field(i)
+		t = sf.Type
+		if t.Kind() == reflect.Pointer {
+			if !sf.IsExported() {
+				return false
+			}
+			t = t.Elem()
+		}
+	}
+	return true
+}
+
+//	Get field by index and allocate nil embedded struct pointers on the way
+func field_alloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+//	Numbers use the decimal separator of the column unless given in the tag
+func (d *decoder) infer_decimals(t Table){
+	for i, f := range d.fields {
+		if f.col != -1 && f.decimal == 0 {
+			d.fields[i].decimal = infer_column(t.column(f.col)).Decimal
+		}
+	}
+}
+
+func decodable(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(type_unmarshaler) || t == type_time {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return decodable(t.Elem())
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+//	Required fields without a column
+func (d *decoder) missing() []Violation {
+	var violations []Violation
+	for _, f := range d.fields {
+		if f.required && f.col == -1 {
+			violations = append(violations, Violation{
+				Line:		-1,
+				Column:		f.name,
+				Message:	"Column missing",
+			})
+		}
+	}
+	return violations
+}
+
+func (d *decoder) decode(row Row, dst reflect.Value) []Violation {
+	var violations []Violation
+	for _, f := range d.fields {
+		var value string
+		if f.col != -1 && f.col < len(row.Row) {
+			value = strings.TrimSpace(row.Row[f.col])
+		}
+		if value == "" {
+			if f.required {
+				violations = append(violations, Violation{row.Line, f.name, value, "Value required"})
+			}
+			continue
+		}
+		
+		if err := f.set(field_alloc(dst, f.index), value); err != nil {
+			violations = append(violations, Violation{row.Line, f.name, value, err.Error()})
+		}
+	}
+	return violations
+}
+
+func (f decode_field) set(v reflect.Value, value string) error {
+	if u, ok := v.Addr().Interface().(Unmarshaler); ok {
+		return u.Unmarshal_csv(value)
+	}
+	if v.Kind() == reflect.Pointer {
+		p := reflect.New(v.Type().Elem())
+		if err := f.set(p.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	
+	decimal := f.decimal
+	if decimal == 0 {
+		decimal = ','
+	}
+	if v.Type() == type_time {
+		t, err := f.parse_time(value)
+		if err != nil {
+			return fmt.Errorf("Invalid date")
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	
+	switch v.Kind() {
+	case reflect.String:
+		if f.decimal_string {
+			n, ok := parse_number(value, decimal)
+			if !ok {
+				return fmt.Errorf("Invalid decimal")
+			}
+			value = n.value
+		}
+		v.SetString(value)
+	case reflect.Bool:
+		b, ok := parse_bool(value)
+		if !ok {
+			var err error
+			if b, err = strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("Invalid boolean")
+			}
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := parse_number(value, decimal)
+		if !ok || n.decimal != 0 || n.percent {
+			return fmt.Errorf("Invalid integer")
+		}
+		i, err := strconv.ParseInt(n.value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("Integer out of range")
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := parse_number(value, decimal)
+		if !ok || n.decimal != 0 || n.percent {
+			return fmt.Errorf("Invalid integer")
+		}
+		i, err := strconv.ParseUint(n.value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("Integer out of range")
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		n, ok := parse_number(value, decimal)
+		if !ok {
+			return fmt.Errorf("Invalid decimal")
+		}
+		fl, err := strconv.ParseFloat(n.value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("Decimal out of range")
+		}
+		v.SetFloat(fl)
+	}
+	return nil
+}
+
+func (f decode_field) parse_time(value string) (time.Time, error){
+	if f.layout != "" {
+		return time.Parse(f.layout, value)
+	}
+	date, tm, ok := date_layout(value)
+	if !ok {
+		return time.Time{}, fmt.Errorf("Unknown date layout")
+	}
+	return time.Parse(date+tm, value)
+}
\ No newline at end of file
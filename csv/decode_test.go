@@ -0,0 +1,169 @@
+package csv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type test_cents int64
+
+func (c *test_cents) Unmarshal_csv(value string) error {
+	n, ok := parse_number(value, ',')
+	if !ok {
+		return errors.New("Invalid amount")
+	}
+	whole, frac, _ := strings.Cut(n.value+".", ".")
+	frac = (strings.TrimSuffix(frac, ".")+"00")[:2]
+	var cents int64
+	for _, c := range whole+frac {
+		if c != '-' {
+			cents = cents * 10 + int64(c - '0')
+		}
+	}
+	if strings.HasPrefix(n.value, "-") {
+		cents = -cents
+	}
+	*c = test_cents(cents)
+	return nil
+}
+
+type test_invoice struct {
+	ID		int			`csv:"Id,required"`
+	Amount	float64		`csv:"Amount,alias=Beløb"`
+	Total	string		`csv:"Total,decimal_string"`
+	Cents	test_cents	`csv:"Beløb"`
+	Paid	*bool		`csv:"Paid"`
+	Date	time.Time	`csv:"Date,layout=02-01-2006"`
+	Note	string
+	Skip	string		`csv:"-"`
+}
+
+func Test_decode(t *testing.T){
+	input := `Id;Beløb;Total;Paid;Date;Note
+1;1.234,50;2.000,10;ja;31-01-2024;first
+x;abc;3;;01-02-2024;second`
+	out, err := NewReader("").Bytes([]byte(input), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	
+	var invoices []test_invoice
+	err = out.Decode(&invoices)
+	var derr *Decode_error
+	if !errors.As(err, &derr) {
+		t.Fatalf("Expected a decode error, got %v", err)
+	}
+	want := []Violation{
+		{3, "Id", "x", "Invalid integer"},
+		{3, "Amount", "abc", "Invalid decimal"},
+		{3, "Beløb", "abc", "Invalid amount"},
+	}
+	if len(derr.Violations) != len(want) {
+		t.Fatalf("Want %d violations, got %+v", len(want), derr.Violations)
+	}
+	for i := range want {
+		if derr.Violations[i] != want[i] {
+			t.Fatalf("Violation %d want: %+v\n\nGot: %+v", i, want[i], derr.Violations[i])
+		}
+	}
+	
+	if len(invoices) != 2 {
+		t.Fatalf("Want 2 rows, got %d", len(invoices))
+	}
+	inv := invoices[0]
+	if inv.ID != 1 || inv.Amount != 1234.5 || inv.Total != "2000.10" || inv.Cents != 123450 || inv.Paid == nil || !*inv.Paid || inv.Note != "first" {
+		t.Fatalf("Unexpected row: %+v", inv)
+	}
+	if !inv.Date.Equal(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected date: %s", inv.Date)
+	}
+	if invoices[1].Paid != nil || invoices[1].Total != "3" {
+		t.Fatalf("Unexpected row: %+v", invoices[1])
+	}
+	
+	var ptrs []*test_invoice
+	if err := out.Decode(&ptrs); err == nil || len(ptrs) != 2 {
+		t.Fatalf("Want 2 rows and an error, got %d %v", len(ptrs), err)
+	}
+	if err := out.Decode(invoices); err == nil {
+		t.Fatal("Expected an error")
+	}
+	
+	var missing []struct{
+		Account	string	`csv:"Account,required"`
+	}
+	if err := out.Decode(&missing); err == nil || err.Error() != "Column missing: Account" {
+		t.Fatalf("Expected error 'Column missing: Account', got '%v'", err)
+	}
+	
+	//	Nil embedded struct pointers are allocated (fields behind unexported pointers are skipped)
+	type Base struct {
+		Note	string
+	}
+	type base struct {
+		Note	string
+	}
+	var embedded []struct{
+		*Base
+		Amount	int	`csv:"Id"`
+	}
+	if err := out.Decode(&embedded); err == nil || embedded[0].Base == nil || embedded[0].Note != "first" || embedded[0].Amount != 1 {
+		t.Fatalf("Unexpected rows: %+v %v", embedded, err)
+	}
+	var unexported []struct{
+		*base
+		Amount	int	`csv:"Id"`
+	}
+	if err := out.Decode(&unexported); err == nil || unexported[0].base != nil || unexported[0].Amount != 1 {
+		t.Fatalf("Unexpected rows: %+v %v", unexported, err)
+	}
+}
+
+func Test_decode_rows(t *testing.T){
+	input := "Id;Note\n1;first\nx;second\n3;third"
+	
+	var (
+		ids		[]int
+		lines	[]int
+	)
+	for v, err := range Decode_rows[test_invoice](NewReader(""), strings.NewReader(input)) {
+		var derr *Decode_error
+		if errors.As(err, &derr) {
+			lines = append(lines, derr.Violations[0].Line)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		ids = append(ids, v.ID)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 || len(lines) != 1 || lines[0] != 3 {
+		t.Fatalf("Unexpected result: ids %v, error lines %v", ids, lines)
+	}
+	
+	//	Decimal separator is inferred as in Table.Decode
+	input = "Id;Amount\n1;1.500\n2;2.25"
+	out, err := NewReader("").Bytes([]byte(input), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var want []test_invoice
+	if err := out.Decode(&want); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	i := 0
+	for v, err := range Decode_rows[test_invoice](NewReader(""), strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if v.Amount != want[i].Amount {
+			t.Fatalf("Row %d want amount %v, got %v", i, want[i].Amount, v.Amount)
+		}
+		i++
+	}
+	if i != 2 || want[0].Amount != 1.5 {
+		t.Fatalf("Unexpected result: %d rows, %+v", i, want)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,249 @@
+package csv
+
+import (
+	"io"
+	"bytes"
+)
+
+type (
+	//	Normalize CRLF and lone CR to LF and count line ending styles outside quoted cells (CR in quoted cells is kept unless quotes are unbalanced)
+	eol struct {
+		unbalanced	bool
+		quoted		bool
+		cr			bool
+		prev		byte
+		lf			int
+		crlf		int
+		lone_cr		int
+	}
+	
+	eol_reader struct {
+		src		io.Reader
+		eol		*eol
+		buf		[]byte
+		err		error
+	}
+	
+	//	Byte offsets in the source where physical lines start (line breaks are found as by eol, but before decoding)
+	line_index struct {
+		unbalanced	bool
+		width		int
+		big_endian	bool
+		quoted		bool
+		cr			bool
+		prev		byte
+		n			int64
+		first		int
+		starts		[]int64
+		partial		[]byte
+	}
+	
+	line_reader struct {
+		src		io.Reader
+		index	*line_index
+	}
+)
+
+func newEol_reader(src io.Reader, e *eol) *eol_reader {
+	return &eol_reader{
+		src:	src,
+		eol:	e,
+	}
+}
+
+func (r *eol_reader) Read(p []byte) (int, error){
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		
+		in := make([]byte, 4096)
+		n, err := r.src.Read(in)
+		r.err = err
+		r.buf = r.eol.convert(r.buf[:0], in[:n])
+		if err != nil {
+			r.buf = r.eol.flush(r.buf)
+		}
+	}
+	
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+//	Convert string with line endings normalized
+func (e *eol) string(s string) string {
+	out := e.convert(make([]byte, 0, len(s)), []byte(s))
+	return string(e.flush(out))
+}
+
+func (e *eol) convert(dst, src []byte) []byte {
+	for _, c := range src {
+		if e.cr {
+			e.cr = false
+			dst = append(dst, '\n')
+			if c == '\n' {
+				e.count(&e.crlf)
+				e.prev = c
+				continue
+			}
+			e.count(&e.lone_cr)
+			e.prev = '\n'
+		}
+		
+		switch c {
+		case '\r':
+			if !e.quoted {
+				e.cr = true
+				continue
+			}
+		case '\n':
+			e.count(&e.lf)
+		case '"':
+			//	Quoted cells start after a separator or line break
+			if !e.unbalanced && (e.quoted || !alnum(e.prev)) {
+				e.quoted = !e.quoted
+			}
+		}
+		dst		= append(dst, c)
+		e.prev	= c
+	}
+	return dst
+}
+
+//	Flush pending CR at end of source
+func (e *eol) flush(dst []byte) []byte {
+	if e.cr {
+		e.cr = false
+		e.count(&e.lone_cr)
+		dst = append(dst, '\n')
+	}
+	return dst
+}
+
+func (e *eol) count(n *int){
+	if !e.quoted {
+		*n++
+	}
+}
+
+func (e *eol) style() string {
+	switch {
+	case e.lf == 0 && e.crlf == 0 && e.lone_cr == 0:
+		return ""
+	case e.lf == 0 && e.lone_cr == 0:
+		return LINE_ENDING_CRLF
+	case e.crlf == 0 && e.lone_cr == 0:
+		return LINE_ENDING_LF
+	case e.lf == 0 && e.crlf == 0:
+		return LINE_ENDING_CR
+	}
+	return LINE_ENDING_MIXED
+}
+
+func alnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+//	Index line starts of source in charset (offset is the length of a stripped BOM)
+func newLine_index(charset string, offset int64, unbalanced bool) *line_index {
+	l := &line_index{
+		unbalanced:	unbalanced,
+		width:		1,
+		n:			offset,
+		first:		1,
+		starts:		[]int64{offset},
+	}
+	if charset == charset_utf16le || charset == charset_utf16be {
+		l.width			= 2
+		l.big_endian	= charset == charset_utf16be
+	}
+	return l
+}
+
+func (l *line_index) scan(b []byte){
+	if len(l.partial) != 0 {
+		b			= append(l.partial, b...)
+		l.partial	= nil
+	}
+	
+	i := 0
+	for ; i + l.width <= len(b); i += l.width {
+		c := b[i]
+		//	Only ASCII is relevant in UTF16 code units
+		if l.width == 2 {
+			lo, hi := b[i], b[i+1]
+			if l.big_endian {
+				lo, hi = hi, lo
+			}
+			c = lo
+			if hi != 0 || lo >= 0x80 {
+				c = 0x80
+			}
+		}
+		
+		pos := l.n + int64(i)
+		if l.cr {
+			l.cr	= false
+			l.prev	= '\n'
+			if c == '\n' {
+				l.starts = append(l.starts, pos + int64(l.width))
+				continue
+			}
+			l.starts = append(l.starts, pos)
+		}
+		
+		switch c {
+		case '\r':
+			if !l.quoted {
+				l.cr = true
+				continue
+			}
+		case '\n':
+			l.starts = append(l.starts, pos + int64(l.width))
+		case '"':
+			if !l.unbalanced && (l.quoted || !alnum(l.prev)) {
+				l.quoted = !l.quoted
+			}
+		}
+		l.prev = c
+	}
+	l.n += int64(i)
+	if i < len(b) {
+		l.partial = append([]byte{}, b[i:]...)
+	}
+}
+
+//	Offset of line (1-based) where lines past the source start at the end (earlier lines are dropped)
+func (l *line_index) offset(line int) int64 {
+	i := max(line - l.first, 0)
+	if i >= len(l.starts) {
+		return l.n
+	}
+	l.starts	= l.starts[i:]
+	l.first		+= i
+	return l.starts[0]
+}
+
+//	Raw bytes of line (1-based) without the line break
+func (l *line_index) line(src []byte, line int) []byte {
+	b := src[l.offset(line):l.offset(line + 1)]
+	for _, c := range []byte{'\n', '\r'} {
+		unit := []byte{c}
+		if l.width == 2 {
+			if l.big_endian {
+				unit = []byte{0, c}
+			} else {
+				unit = []byte{c, 0}
+			}
+		}
+		b = bytes.TrimSuffix(b, unit)
+	}
+	return b
+}
+
+func (r *line_reader) Read(p []byte) (int, error){
+	n, err := r.src.Read(p)
+	r.index.scan(p[:n])
+	return n, err
+}
\ No newline at end of file
@@ -1,14 +1,83 @@
-package csv
-
-type Error struct {
-	s 	string
-	err error
-}
-
-func (e *Error) Error() string {
-	return e.s
-}
-
-func (e *Error) Unwrap() error {
-	return e.err
+package csv
+
+import "strings"
+
+var (
+	ErrOptions			= &Error{s: "Invalid options", code: "options"}
+	ErrCharset			= &Error{s: "Unknown charset", code: "charset"}
+	ErrSeparator		= &Error{s: "Unable to find separator", code: "separator"}
+	ErrRead				= &Error{s: "Unable to read CSV", code: "read"}
+	ErrEmpty			= &Error{s: "CSV empty", code: "empty"}
+	ErrOneColumn		= &Error{s: "CSV must have more than one column", code: "one_column"}
+	ErrTooFewHeaders	= &Error{s: "CSV has too few column headers", code: "too_few_headers"}
+	ErrColumnsNotEqual	= &Error{s: "Columns in CSV not equal", code: "columns_not_equal"}
+	ErrHeaderRequired	= &Error{s: "Column headers in CSV required", code: "header_required"}
+	ErrHeaderEmpty		= &Error{s: "Column headers cannot be empty", code: "header_empty"}
+	ErrInvalidEncoding	= &Error{s: "Invalid CSV file encoding", code: "invalid_encoding"}
+	ErrParse			= &Error{s: "Unable to parse CSV", code: "parse"}
+	ErrWorkbook			= &Error{s: "Unable to read workbook", code: "workbook"}
+	ErrNoSheets			= &Error{s: "Workbook has no sheets", code: "no_sheets"}
+	ErrSheetNotFound	= &Error{s: "Sheet not found", code: "sheet_not_found"}
+	ErrDecode			= &Error{s: "Unable to decode", code: "decode"}
+	ErrWrite			= &Error{s: "Unable to write", code: "write"}
+	ErrRoundTrip		= &Error{s: "Round trip failed", code: "round_trip"}
+	ErrMaxBytes			= &Error{s: "Max bytes exceeded", code: "max_bytes"}
+	ErrMaxRows			= &Error{s: "Max rows exceeded", code: "max_rows"}
+	ErrMaxCols			= &Error{s: "Max columns exceeded", code: "max_cols"}
+	ErrMaxCell			= &Error{s: "Max cell length exceeded", code: "max_cell"}
+	ErrMaxConvertTime	= &Error{s: "Max conversion time exceeded", code: "max_convert_time"}
+	ErrCanceled			= &Error{s: "Parsing canceled", code: "canceled"}
+	ErrFormat			= &Error{s: "Unsupported file format", code: "format"}
+)
+
+//	Error with code (compare with the Err sentinels using errors.Is) and the 1-based line and column of the cause when known
+type Error struct {
+	s		string
+	err		error
+	code	string
+	Line	int
+	Column	int
+}
+
+//	Message with the cause unless the message already includes it
+func (e *Error) Error() string {
+	if e.err != nil {
+		if cause := e.err.Error(); !strings.Contains(e.s, cause) {
+			return e.s+": "+cause
+		}
+	}
+	return e.s
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+func (e *Error) Code() string {
+	return e.code
+}
+
+//	Errors match the sentinel of the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.code != "" && t.code == e.code
+}
+
+//	New error of kind (message defaults to the kind message)
+func new_error(kind *Error, s string, err error) *Error {
+	if s == "" {
+		s = kind.s
+	}
+	return &Error{
+		s:		s,
+		err:	err,
+		code:	kind.code,
+	}
+}
+
+//	Set position of the cause
+func (e *Error) at(line, column int) *Error {
+	e.Line		= line
+	e.Column	= column
+	return e
 }
\ No newline at end of file
@@ -0,0 +1,90 @@
+package csv
+
+import (
+	"io"
+	"bytes"
+	"strings"
+	"archive/zip"
+	"compress/gzip"
+)
+
+var (
+	magic_ole2	= []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	magic_zip	= []byte("PK\x03\x04")
+	magic_gzip	= []byte{0x1F, 0x8B}
+)
+
+//	Parse reader (format is sniffed from the content)
+func (r *Reader) Read(src io.Reader) (Table, error){
+	if err := r.read_src(src); err != nil {
+		return Table{}, err
+	}
+	return r.parse("")
+}
+
+//	Detect format from the content and log if the given mimetype differs (gzip is decompressed)
+func (r *Reader) format(mimetype string) (string, error){
+	if bytes.HasPrefix(r.src, magic_gzip) {
+		gz, err := gzip.NewReader(bytes.NewReader(r.src))
+		if err != nil {
+			return "", r.log_fail(new_error(ErrFormat, "Invalid gzip file", err))
+		}
+		if err := r.read_src(gz); err != nil {
+			return "", err
+		}
+		r.log_append(log_info(LOG_FORMAT, "Gzip decompressed"))
+		if bytes.HasPrefix(r.src, magic_gzip) {
+			return "", r.log_fail(new_error(ErrFormat, "Nested gzip files are not supported", nil))
+		}
+	}
+	
+	detected, err := sniff_format(r.src)
+	if err != nil {
+		return "", r.log_fail(err)
+	}
+	if mimetype != "" && format_name(mimetype) != format_name(detected) {
+		r.log_append(log_warning(LOG_FORMAT, "Format mismatch: "+format_name(mimetype)+" given, "+format_name(detected)+" detected"))
+	}
+	return detected, nil
+}
+
+//	Sniff container from magic bytes (text is returned as an empty mimetype)
+func sniff_format(b []byte) (string, *Error){
+	switch {
+	case bytes.HasPrefix(b, magic_ole2):
+		return MIME_XLS, nil
+	case bytes.HasPrefix(b, magic_zip):
+		z, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return "", new_error(ErrFormat, "Invalid ZIP archive", err)
+		}
+		for _, f := range z.File {
+			switch strings.TrimPrefix(f.Name, "/") {
+			case "xl/workbook.xml":
+				return MIME_XLXS, nil
+			case "content.xml":
+				return MIME_ODS, nil
+			}
+		}
+		return "", new_error(ErrFormat, "ZIP archive is not an XLSX or ODS file", nil)
+	case is_html(b):
+		return MIME_HTML, nil
+	}
+	return "", nil
+}
+
+//	HTML table exports (often saved as .xls)
+func is_html(b []byte) bool {
+	b = bytes.TrimLeft(bytes.TrimPrefix(b, []byte(BOM_UTF8)), " \t\r\n")
+	if len(b) == 0 || b[0] != '<' {
+		return false
+	}
+	return bytes.Contains(bytes.ToLower(b[:min(len(b), sniff_size)]), []byte("<table"))
+}
+
+func format_name(mimetype string) string {
+	if name, ok := workbook_formats[mimetype]; ok {
+		return name
+	}
+	return "CSV"
+}
\ No newline at end of file
@@ -0,0 +1,95 @@
+package csv
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"compress/gzip"
+)
+
+func Test_read_format(t *testing.T){
+	xlsx := test_xlsx(map[string]string{
+		"Data": `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>3</v></c><c r="B2" s="1"><v>45322</v></c></row>`,
+	})
+	ods := test_ods(map[string]string{
+		"Data":	`<table:table-row>
+	<table:table-cell office:value-type="string"><text:p>Name</text:p></table:table-cell>
+	<table:table-cell office:value-type="string"><text:p>Amount</text:p></table:table-cell>
+</table:table-row>
+<table:table-row>
+	<table:table-cell office:value-type="string"><text:p>Ærø</text:p></table:table-cell>
+	<table:table-cell office:value-type="float" office:value="12.5"><text:p>12,50</text:p></table:table-cell>
+</table:table-row>`,
+	})
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte("Name;Amount\nÆrø;12,50"))
+	w.Close()
+	
+	html := `<!DOCTYPE html>
+<html><head><style>td { color: red }</style></head><body>
+<table>
+	<tr><th>Name</th><th>Note</th><th>Amount</th></tr>
+	<!-- <tr><td>hidden</td></tr> -->
+	<tr><td>&AElig;r&oslash;</td><td><b>Two</b>
+		lines<br>here</td><td>12,50</td></tr>
+	<tr><td colspan="2">Total</td><td>12,50</td></tr>
+</table>
+</body></html>`
+
+	tests := []struct{
+		input	[]byte
+		header	string
+		rows	string
+	}{{
+		input:	xlsx,
+		header:	"Name,Date",
+		rows:	"Ærø,2024-01-31",
+	},{
+		input:	ods,
+		header:	"Name,Amount",
+		rows:	"Ærø,12.5",
+	},{
+		input:	gz.Bytes(),
+		header:	"Name,Amount",
+		rows:	"Ærø,12,50",
+	},{
+		input:	[]byte(html),
+		header:	"Name,Note,Amount",
+		rows:	"Ærø,Two lines\nhere,12,50\nTotal,,12,50",
+	}}
+	for _, tt := range tests {
+		out, err := NewReader("").Read(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		verify_table(t, out, tt.header, tt.rows)
+	}
+	
+	//	Wrong mimetype is logged and the detected format is used
+	r := NewReader("")
+	out, err := r.Bytes(xlsx, "text/csv")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	verify_table(t, out, "Name,Date", "Ærø,2024-01-31")
+	if !slices.Contains(r.Log(), "Format mismatch: CSV given, XLSX detected") {
+		t.Fatalf("Expected mismatch in log: %s", strings.Join(r.Log(), "\n"))
+	}
+	
+	r = NewReader("")
+	if _, err := r.Bytes([]byte("Name;Amount\nÆrø;12,50"), MIME_XLS); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !slices.Contains(r.Log(), "Format mismatch: XLS given, CSV detected") {
+		t.Fatalf("Expected mismatch in log: %s", strings.Join(r.Log(), "\n"))
+	}
+	
+	_, err = NewReader("").Read(bytes.NewReader(test_zip(map[string]string{"readme.txt": "text"})))
+	if !errors.Is(err, ErrFormat) {
+		t.Fatalf("Expected format error, got %v", err)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,205 @@
+package csv
+
+import (
+	"slices"
+	"regexp"
+	"strings"
+)
+
+const (
+	//	Lowest score accepted as a match
+	header_min_score = 0.6
+)
+
+var (
+	//	Units and notes like "(DKK)" or "[kr]" are not part of the name
+	re_header_note = regexp.MustCompile(`\(.*?\)|\[.*?\]`)
+	
+	diacritics = strings.NewReplacer(
+		"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "ā", "a",
+		"æ", "ae", "ç", "c", "ð", "d",
+		"è", "e", "é", "e", "ê", "e", "ë", "e",
+		"ì", "i", "í", "i", "î", "i", "ï", "i",
+		"ñ", "n",
+		"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o",
+		"ù", "u", "ú", "u", "û", "u", "ü", "u",
+		"ý", "y", "ÿ", "y",
+		"ß", "ss", "þ", "th",
+	)
+)
+
+type (
+	Header_match struct {
+		Field		string	`json:"field"`
+		Column		int		`json:"column"`
+		Header		string	`json:"header"`
+		Confidence	float64	`json:"confidence"`
+	}
+	
+	//	Matched fields, header columns without a field, fields without a column and fields matching several columns equally well
+	Header_mapping struct {
+		Matches		[]Header_match	`json:"matches"`
+		Unmapped	[]string		`json:"unmapped"`
+		Missing		[]string		`json:"missing"`
+		Ambiguous	[]string		`json:"ambiguous"`
+	}
+	
+	header_pair struct {
+		field	string
+		col		int
+		score	float64
+	}
+)
+
+//	Map canonical field names (with aliases) to header columns
+//	Names are compared after case folding, diacritic folding and stripping of units and non-letters, then by edit distance
+func (h Header) Map(fields map[string][]string) Header_mapping {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	
+	keys := make([]string, len(h))
+	for c, value := range h {
+		keys[c] = header_key(value)
+	}
+	
+	var pairs []header_pair
+	for _, name := range names {
+		for c, value := range h {
+			score := 0.0
+			for _, alias := range append([]string{name}, fields[name]...) {
+				score = max(score, header_score(alias, value, keys[c]))
+			}
+			if score >= header_min_score {
+				pairs = append(pairs, header_pair{name, c, score})
+			}
+		}
+	}
+	//	Best scores are assigned first
+	slices.SortStableFunc(pairs, func(a, b header_pair) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		}
+		return 0
+	})
+	
+	var (
+		m			Header_mapping
+		field_done	= map[string]bool{}
+		col_done	= map[int]bool{}
+		col_tied	= map[int]bool{}
+	)
+	for i, p := range pairs {
+		if field_done[p.field] || col_done[p.col] {
+			continue
+		}
+		
+		//	Another open pair with the same score competing for the field or the column
+		var tied []header_pair
+		for _, q := range pairs[i+1:] {
+			if q.score != p.score {
+				break
+			}
+			if !field_done[q.field] && !col_done[q.col] && (q.field == p.field || q.col == p.col) {
+				tied = append(tied, q)
+			}
+		}
+		//	Tied columns are not assigned to other fields and are reported as unmapped
+		if len(tied) != 0 {
+			for _, q := range append(tied, p) {
+				if !field_done[q.field] {
+					field_done[q.field] = true
+					m.Ambiguous = append(m.Ambiguous, q.field)
+				}
+				col_done[q.col]	= true
+				col_tied[q.col]	= true
+			}
+			continue
+		}
+		
+		field_done[p.field]	= true
+		col_done[p.col]		= true
+		m.Matches = append(m.Matches, Header_match{
+			Field:		p.field,
+			Column:		p.col,
+			Header:		h[p.col],
+			Confidence:	p.score,
+		})
+	}
+	
+	slices.SortFunc(m.Matches, func(a, b Header_match) int {
+		return a.Column - b.Column
+	})
+	for c, value := range h {
+		if (!col_done[c] || col_tied[c]) && value != "" {
+			m.Unmapped = append(m.Unmapped, value)
+		}
+	}
+	for _, name := range names {
+		if !field_done[name] {
+			m.Missing = append(m.Missing, name)
+		}
+	}
+	slices.Sort(m.Ambiguous)
+	return m
+}
+
+//	Score name against header value (1 is an exact match)
+func header_score(name, value, key string) float64 {
+	switch {
+	case name == value:
+		return 1
+	case strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(value)):
+		return 0.95
+	}
+	
+	name = header_key(name)
+	if name == "" || key == "" {
+		return 0
+	}
+	if name == key {
+		return 0.9
+	}
+	
+	//	Allow one edit per 4 letters
+	l := max(len([]rune(name)), len([]rune(key)))
+	d := edit_distance(name, key)
+	if d > l / 4 {
+		return 0
+	}
+	return 0.9 * (1 - float64(d) / float64(l))
+}
+
+//	Normalize header for comparison
+func header_key(s string) string {
+	s = re_header_note.ReplaceAllString(s, "")
+	s = diacritics.Replace(strings.ToLower(s))
+	return re_col_heading.ReplaceAllString(s, "")
+}
+
+//	Levenshtein distance between strings
+func edit_distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb) + 1)
+	cur := make([]int, len(rb) + 1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j] + 1, cur[j-1] + 1, prev[j-1] + cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
\ No newline at end of file
@@ -0,0 +1,44 @@
+package csv
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_header_map(t *testing.T){
+	header := Header{"Dato", "Bel�b", "Sum (DKK)", "Tekst", "Konto 1", "Konto 2", "Ukendt"}
+	fields := map[string][]string{
+		"Date":		{"Dato"},
+		"Amount":	{"Beløb"},
+		"Total":	{"Sum"},
+		"Text":		{"Tekst", "Description"},
+		"Account":	{"Konto"},
+		"Vat":		{"Moms"},
+	}
+	m := header.Map(fields)
+	
+	edit := 1.0 / 5
+	want := []Header_match{
+		{"Date", 0, "Dato", 1},
+		{"Amount", 1, "Bel�b", 0.9 * (1 - edit)},
+		{"Total", 2, "Sum (DKK)", 0.9},
+		{"Text", 3, "Tekst", 1},
+	}
+	if len(m.Matches) != len(want) {
+		t.Fatalf("Want %d matches, got %+v", len(want), m.Matches)
+	}
+	for i := range want {
+		if m.Matches[i] != want[i] {
+			t.Fatalf("Match %d want: %+v\n\nGot: %+v", i, want[i], m.Matches[i])
+		}
+	}
+	if !slices.Equal(m.Ambiguous, []string{"Account"}) {
+		t.Fatalf("Want ambiguous [Account], got %v", m.Ambiguous)
+	}
+	if !slices.Equal(m.Missing, []string{"Vat"}) {
+		t.Fatalf("Want missing [Vat], got %v", m.Missing)
+	}
+	if !slices.Equal(m.Unmapped, []string{"Konto 1", "Konto 2", "Ukendt"}) {
+		t.Fatalf("Want unmapped [Konto 1 Konto 2 Ukendt], got %v", m.Unmapped)
+	}
+}
\ No newline at end of file
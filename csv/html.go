@@ -0,0 +1,210 @@
+package csv
+
+import (
+	"html"
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type (
+	//	HTML document where each table is a sheet
+	html_doc struct {
+		names	[]string
+		tables	[][][]string
+	}
+	
+	html_cell struct {
+		value	strings.Builder
+		colspan	int
+		open	bool
+		space	bool
+	}
+)
+
+func open_html(b []byte, lim *limits) (*html_doc, error){
+	b = bytes.TrimPrefix(b, []byte(BOM_UTF8))
+	s := string(b)
+	if !utf8.Valid(b) {
+		s = decode_charset(b, detect_charset(b))
+	}
+	
+	var (
+		doc		= &html_doc{}
+		table	[][]string
+		line	[]string
+		cell	html_cell
+		depth	int
+	)
+	end_cell := func() error {
+		if !cell.open {
+			return nil
+		}
+		cell.open = false
+		value := strings.TrimSpace(html.UnescapeString(cell.value.String()))
+		if err := lim.sheet_cell(len(table), len(line) + cell.colspan - 1, value); err != nil {
+			return err
+		}
+		line = append(line, value)
+		for range cell.colspan - 1 {
+			line = append(line, "")
+		}
+		return nil
+	}
+	end_row := func() error {
+		if err := end_cell(); err != nil {
+			return err
+		}
+		if line != nil {
+			table	= append(table, line)
+			line	= nil
+		}
+		return nil
+	}
+	
+	for i := 0; i < len(s); {
+		j := strings.IndexByte(s[i:], '<')
+		if j == -1 {
+			j = len(s) - i
+		}
+		if cell.open {
+			cell.text(s[i:i+j])
+		}
+		i += j
+		if i == len(s) {
+			break
+		}
+		
+		if strings.HasPrefix(s[i:], "<!--") {
+			k := strings.Index(s[i:], "-->")
+			if k == -1 {
+				break
+			}
+			i += k + 3
+			continue
+		}
+		k := strings.IndexByte(s[i:], '>')
+		if k == -1 {
+			break
+		}
+		name, attrs, closing := html_tag(s[i+1:i+k])
+		i += k + 1
+		
+		//	Skip content of script and style
+		if (name == "script" || name == "style") && !closing {
+			k := strings.Index(strings.ToLower(s[i:]), "</"+name)
+			if k == -1 {
+				break
+			}
+			i += k
+			continue
+		}
+		
+		//	Nested tables are read as cell text
+		if depth > 1 && name != "table" {
+			continue
+		}
+		var err error
+		switch {
+		case name == "table" && !closing:
+			depth++
+			if depth == 1 {
+				table = nil
+			}
+		case name == "table":
+			depth--
+			if depth == 0 {
+				if err = end_row(); err == nil {
+					doc.tables	= append(doc.tables, table)
+					doc.names	= append(doc.names, "Table "+strconv.Itoa(len(doc.tables)))
+				}
+			}
+		case depth == 0:
+		case name == "tr":
+			err = end_row()
+		case (name == "td" || name == "th") && !closing:
+			if err = end_cell(); err == nil {
+				cell.value.Reset()
+				cell.open		= true
+				cell.space		= false
+				cell.colspan	= 1
+				if n, err := strconv.Atoi(html_attr(attrs, "colspan")); err == nil && n > 1 {
+					cell.colspan = min(n, 1024)
+				}
+			}
+		case name == "td" || name == "th":
+			err = end_cell()
+		case name == "br" && cell.open:
+			cell.value.WriteByte('\n')
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func (d *html_doc) sheet_names() []string {
+	return d.names
+}
+
+func (d *html_doc) rows(i int) ([][]string, error){
+	return d.tables[i], nil
+}
+
+//	Append text with whitespace collapsed as in HTML rendering
+func (c *html_cell) text(s string){
+	if s == "" {
+		return
+	}
+	if unicode.IsSpace(rune(s[0])) {
+		c.space = true
+	}
+	for _, field := range strings.Fields(s) {
+		if c.space && c.value.Len() != 0 && !strings.HasSuffix(c.value.String(), "\n") {
+			c.value.WriteByte(' ')
+		}
+		c.value.WriteString(field)
+		c.space = true
+	}
+	c.space = unicode.IsSpace(rune(s[len(s)-1]))
+}
+
+//	Lowercase tag name, attributes and if the tag is closing
+func html_tag(tag string) (string, string, bool){
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimPrefix(tag, "/")
+	end := strings.IndexAny(tag, " \t\r\n/")
+	if end == -1 {
+		end = len(tag)
+	}
+	return strings.ToLower(tag[:end]), tag[end:], closing
+}
+
+//	Attribute value (quoted or unquoted)
+func html_attr(attrs, name string) string {
+	lower := strings.ToLower(attrs)
+	for i := 0; ; {
+		k := strings.Index(lower[i:], name)
+		if k == -1 {
+			return ""
+		}
+		i += k + len(name)
+		if k := i - len(name) - 1; k >= 0 && !strings.ContainsRune(" \t\r\n", rune(lower[k])) {
+			continue
+		}
+		rest := strings.TrimLeft(attrs[i:], " \t\r\n")
+		if !strings.HasPrefix(rest, "=") {
+			continue
+		}
+		rest = strings.TrimLeft(rest[1:], " \t\r\n")
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			value, _, _ := strings.Cut(rest[1:], rest[:1])
+			return value
+		}
+		value, _, _ := strings.Cut(rest, " ")
+		return value
+	}
+}
\ No newline at end of file
@@ -0,0 +1,370 @@
+package csv
+
+import (
+	"time"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	TYPE_EMPTY		= "empty"
+	TYPE_TEXT		= "text"
+	TYPE_BOOL		= "bool"
+	TYPE_INT		= "int"
+	TYPE_DECIMAL	= "decimal"
+	TYPE_DATE		= "date"
+	TYPE_DATETIME	= "datetime"
+	
+	//	Share of values a type must match before the column gets that type
+	infer_threshold = 0.5
+)
+
+var (
+	bool_values = map[string]bool{
+		"true":		true,
+		"false":	false,
+		"yes":		true,
+		"no":		false,
+		"ja":		true,
+		"nej":		false,
+		"sand":		true,
+		"falsk":	false,
+	}
+	
+	//	Longest first so "kr." is stripped before "kr"
+	currencies = []string{"DKK", "EUR", "USD", "GBP", "SEK", "NOK", "CHF", "kr.", "kr", "€", "$", "£"}
+	
+	//	European day-first layouts are tried before US month-first
+	date_layouts = []string{
+		"2006-1-2",
+		"2-1-2006",
+		"2.1.2006",
+		"2/1/2006",
+		"1/2/2006",
+		"2006/1/2",
+		"2.1.06",
+		"2-1-06",
+		"2/1/06",
+		"2 Jan 2006",
+		"Jan 2, 2006",
+	}
+	
+	time_layouts = []string{
+		"15:04:05Z07:00",
+		"15:04:05",
+		"15:04",
+		"15.04.05",
+		"15.04",
+	}
+)
+
+type (
+	//	Inferred column type (decimal separator for numbers and Go time layout for dates)
+	Column_type struct {
+		Name		string	`json:"name"`
+		Type		string	`json:"type"`
+		Confidence	float64	`json:"confidence"`
+		Decimal		rune	`json:"decimal,omitempty"`
+		Layout		string	`json:"layout,omitempty"`
+	}
+	
+	number struct {
+		value		string
+		decimal		rune
+		ambiguous	bool
+		percent		bool
+	}
+)
+
+//	Infer type of each column from its values (confidence is the share of non-empty values matching the type)
+func (t Table) Infer() []Column_type {
+	cols := len(t.Header)
+	for _, row := range t.Rows {
+		cols = max(cols, len(row.Row))
+	}
+	
+	types := make([]Column_type, cols)
+	for c := range types {
+		types[c] = infer_column(t.column(c))
+		if c < len(t.Header) {
+			types[c].Name = t.Header[c]
+		}
+	}
+	return types
+}
+
+func infer_column(values []string) Column_type {
+	var (
+		total		int
+		bools		int
+		ints		int
+		numbers		int
+		timed		int
+		votes		= map[rune]int{}
+		dates		= map[string]int{}
+		times		= map[string]int{}
+		ambiguous	[]string
+	)
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		total++
+		
+		if _, ok := parse_bool(v); ok {
+			bools++
+			continue
+		}
+		if n, ok := parse_number(v, 0); ok {
+			numbers++
+			switch {
+			case n.ambiguous:
+				ambiguous = append(ambiguous, v)
+			case n.decimal != 0:
+				votes[n.decimal]++
+			case !n.percent:
+				ints++
+			}
+			continue
+		}
+		if date, tm, ok := date_layout(v); ok {
+			dates[date]++
+			if tm != "" {
+				times[tm]++
+				timed++
+			}
+		}
+	}
+	if total == 0 {
+		return Column_type{
+			Type: TYPE_EMPTY,
+		}
+	}
+	
+	//	Values like 1.234 are thousands or decimals depending on the rest of the column (comma as in Danish exports by default)
+	decimal := ','
+	if votes['.'] > votes[','] {
+		decimal = '.'
+	}
+	for _, v := range ambiguous {
+		if n, _ := parse_number(v, decimal); n.decimal == 0 && !n.percent {
+			ints++
+		}
+	}
+	
+	ct := Column_type{
+		Type: TYPE_TEXT,
+	}
+	best := 0
+	if bools > best {
+		ct.Type	= TYPE_BOOL
+		best	= bools
+	}
+	if numbers > best {
+		ct.Type		= TYPE_DECIMAL
+		ct.Decimal	= decimal
+		best		= numbers
+		if ints == numbers {
+			ct.Type = TYPE_INT
+		}
+	}
+	if layout, n := most_common(dates, date_layouts); n > best {
+		ct.Type		= TYPE_DATE
+		ct.Decimal	= 0
+		ct.Layout	= layout
+		best		= n
+		if timed != 0 {
+			tm, _ := most_common(times, nil)
+			ct.Type		= TYPE_DATETIME
+			ct.Layout	+= tm
+		}
+	}
+	
+	share := float64(best) / float64(total)
+	if share < infer_threshold {
+		return Column_type{
+			Type:		TYPE_TEXT,
+			Confidence:	1 - share,
+		}
+	}
+	ct.Confidence = share
+	return ct
+}
+
+//	Parse boolean (true/false, yes/no, ja/nej)
+func parse_bool(s string) (bool, bool){
+	b, ok := bool_values[strings.ToLower(strings.TrimSpace(s))]
+	return b, ok
+}
+
+//	Parse number with thousands separators, currency and percent sign to a plain decimal string (a zero decimal separator marks a lone 1.234 or 1,234 as ambiguous)
+func parse_number(s string, decimal rune) (number, bool){
+	var (
+		n		number
+		neg		bool
+		signed	bool
+	)
+	s = strings.TrimSpace(s)
+	if len(s) > 2 && s[0] == '(' && s[len(s)-1] == ')' {
+		s		= strings.TrimSpace(s[1:len(s)-1])
+		neg		= true
+		signed	= true
+	}
+	
+	//	Sign, currency and percent may be on either side of the digits
+	for {
+		before := s
+		if !signed {
+			if rest, ok := strings.CutPrefix(s, "-"); ok {
+				s, neg, signed = rest, true, true
+			} else if rest, ok := strings.CutSuffix(s, "-"); ok {
+				s, neg, signed = rest, true, true
+			} else if rest, ok := strings.CutPrefix(s, "+"); ok {
+				s, signed = rest, true
+			}
+		}
+		if rest, ok := strings.CutSuffix(s, "%"); ok && !n.percent {
+			s			= rest
+			n.percent	= true
+		}
+		for _, c := range currencies {
+			if len(s) > len(c) && strings.EqualFold(s[:len(c)], c) {
+				s = s[len(c):]
+				break
+			}
+			if len(s) > len(c) && strings.EqualFold(s[len(s)-len(c):], c) {
+				s = s[:len(s)-len(c)]
+				break
+			}
+		}
+		s = strings.TrimSpace(s)
+		if s == before {
+			break
+		}
+	}
+	
+	//	Split into digit groups and the separators between them
+	var (
+		groups	[]string
+		seps	[]rune
+		start	int
+	)
+	for i, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			continue
+		case c == '.', c == ',', c == ' ', c == '\'', c == '\u00a0':
+			if i == start {
+				return n, false
+			}
+			groups	= append(groups, s[start:i])
+			seps	= append(seps, c)
+			start	= i + utf8.RuneLen(c)
+		default:
+			return n, false
+		}
+	}
+	if start == len(s) {
+		return n, false
+	}
+	groups = append(groups, s[start:])
+	
+	integer := groups
+	var fraction string
+	if len(seps) != 0 {
+		last := seps[len(seps)-1]
+		switch {
+		case last != '.' && last != ',':
+		//	Used more than once it can only group thousands
+		case strings.Count(string(seps), string(last)) > 1:
+		//	Another separator before it groups thousands
+		case len(seps) > 1:
+			n.decimal = last
+		case len(groups[1]) == 3 && len(groups[0]) <= 3 && groups[0] != "0":
+			switch decimal {
+			case 0:
+				n.decimal	= last
+				n.ambiguous	= true
+			case last:
+				n.decimal	= last
+			}
+		default:
+			n.decimal = last
+		}
+		
+		if n.decimal != 0 {
+			integer		= groups[:len(groups)-1]
+			fraction	= groups[len(groups)-1]
+		}
+		for i, g := range integer[1:] {
+			if len(g) != 3 || len(integer[0]) > 3 || seps[i] == n.decimal {
+				return n, false
+			}
+		}
+	}
+	
+	digits := strings.Join(integer, "")
+	//	Leading zeros are kept as text (account numbers, postal codes)
+	if len(digits) > 1 && digits[0] == '0' {
+		return n, false
+	}
+	
+	var b strings.Builder
+	if neg {
+		b.WriteByte('-')
+	}
+	b.WriteString(digits)
+	if fraction != "" {
+		b.WriteByte('.')
+		b.WriteString(fraction)
+	}
+	n.value = b.String()
+	return n, true
+}
+
+//	Find date layout (and time layout with its separator) matching the value
+func date_layout(s string) (string, string, bool){
+	date, tm := s, ""
+	if i := strings.LastIndexAny(s, " T"); i != -1 && strings.IndexByte(s[i:], ':') != -1 {
+		date, tm = s[:i], s[i:]
+	}
+	
+	for _, layout := range date_layouts {
+		if _, err := time.Parse(layout, date); err != nil {
+			continue
+		}
+		if tm == "" {
+			return layout, "", true
+		}
+		for _, tl := range time_layouts {
+			if _, err := time.Parse(tl, tm[1:]); err == nil {
+				return layout, tm[:1]+tl, true
+			}
+		}
+		return "", "", false
+	}
+	return "", "", false
+}
+
+//	Most frequent key (ties go to the earliest in order)
+func most_common(counts map[string]int, order []string) (string, int){
+	var (
+		best	string
+		n		int
+	)
+	for _, key := range order {
+		if counts[key] > n {
+			best, n = key, counts[key]
+		}
+	}
+	if order != nil {
+		return best, n
+	}
+	for key, c := range counts {
+		if c > n || c == n && key < best {
+			best, n = key, c
+		}
+	}
+	return best, n
+}
\ No newline at end of file
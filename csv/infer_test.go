@@ -0,0 +1,69 @@
+package csv
+
+import (
+	"testing"
+)
+
+func Test_infer(t *testing.T){
+	input := `ID;Amount;Rate;Date;Paid;Account;Note;Created
+1;1.234,56;12,5 %;31-01-2024;ja;00123;first;2024-01-31 12:30
+2;-12,00 kr.;5%;1-2-2024;nej;00456;second;2024-02-01 08:00:15
+1.001;DKK 1.000;0,5%;15-02-2024;ja;00789;3;2024-02-15 23:59`
+	out, err := NewReader("").Bytes([]byte(input), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	
+	share := 1.0 / 3
+	want := []Column_type{
+		{Name: "ID", Type: TYPE_INT, Confidence: 1, Decimal: ','},
+		{Name: "Amount", Type: TYPE_DECIMAL, Confidence: 1, Decimal: ','},
+		{Name: "Rate", Type: TYPE_DECIMAL, Confidence: 1, Decimal: ','},
+		{Name: "Date", Type: TYPE_DATE, Confidence: 1, Layout: "2-1-2006"},
+		{Name: "Paid", Type: TYPE_BOOL, Confidence: 1},
+		{Name: "Account", Type: TYPE_TEXT, Confidence: 1},
+		{Name: "Note", Type: TYPE_TEXT, Confidence: 1 - share},
+		{Name: "Created", Type: TYPE_DATETIME, Confidence: 1, Layout: "2006-1-2 15:04"},
+	}
+	got := out.Infer()
+	if len(got) != len(want) {
+		t.Fatalf("Want %d columns, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Column %d want: %+v\n\nGot: %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func Test_parse_number(t *testing.T){
+	tests := []struct{
+		input	string
+		decimal	rune
+		value	string
+		ok		bool
+	}{
+		{"1.234,56", 0, "1234.56", true},
+		{"1,234.56", 0, "1234.56", true},
+		{"1 234 567", 0, "1234567", true},
+		{"1.234", ',', "1234", true},
+		{"1.234", '.', "1.234", true},
+		{"(12,50)", 0, "-12.50", true},
+		{"€ 9,95", 0, "9.95", true},
+		{"45 %", 0, "45", true},
+		{"12-", 0, "-12", true},
+		{"0,5", 0, "0.5", true},
+		{"007", 0, "", false},
+		{"1.23.4", 0, "", false},
+		{"1,2,3", 0, "", false},
+		{"+45 12 34 56 78", 0, "", false},
+		{"--5", 0, "", false},
+		{"abc", 0, "", false},
+	}
+	for _, tt := range tests {
+		n, ok := parse_number(tt.input, tt.decimal)
+		if ok != tt.ok || ok && n.value != tt.value {
+			t.Fatalf("%q want %q %t, got %q %t", tt.input, tt.value, tt.ok, n.value, ok)
+		}
+	}
+}
\ No newline at end of file
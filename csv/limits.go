@@ -0,0 +1,190 @@
+package csv
+
+import (
+	"io"
+	"fmt"
+	"time"
+	"context"
+)
+
+const (
+	//	Rows read between checks of the context
+	cancel_interval = 1024
+)
+
+type (
+	//	Resource limits for untrusted input (zero is unlimited) and the context of the caller
+	limits struct {
+		bytes		int64
+		rows		int
+		cols		int
+		cell		int
+		convert		time.Duration
+		deadline	time.Time
+		ctx			context.Context
+	}
+	
+	//	Fails once more than the max bytes are read
+	limit_reader struct {
+		r	io.Reader
+		l	*limits
+		n	int64
+	}
+	
+	//	Checks the conversion deadline and the context before each read
+	convert_reader struct {
+		r	io.Reader
+		l	*limits
+	}
+	
+	limit_read_closer struct {
+		io.Reader
+		io.Closer
+	}
+)
+
+//	Max bytes of the source (also applied to each decompressed XLSX and ODS part)
+func (r *Reader) Max_bytes(n int64) *Reader {
+	r.limits.bytes = n
+	return r
+}
+
+//	Max rows including the column header (sheet row number in workbooks)
+func (r *Reader) Max_rows(n int) *Reader {
+	r.limits.rows = n
+	return r
+}
+
+//	Max columns in a row
+func (r *Reader) Max_cols(n int) *Reader {
+	r.limits.cols = n
+	return r
+}
+
+//	Max bytes in a cell
+func (r *Reader) Max_cell(n int) *Reader {
+	r.limits.cell = n
+	return r
+}
+
+//	Max time to convert XLS, XLSX and ODS workbooks to rows
+func (r *Reader) Max_convert_time(d time.Duration) *Reader {
+	r.limits.convert = d
+	return r
+}
+
+//	Check source size
+func (l *limits) size(n int64) *Error {
+	if l.bytes > 0 && n > l.bytes {
+		return l.bytes_error()
+	}
+	return nil
+}
+
+func (l *limits) bytes_error() *Error {
+	return new_error(ErrMaxBytes, fmt.Sprintf("Max bytes exceeded: %d", l.bytes), nil)
+}
+
+//	Wrap reader with the byte limit
+func (l *limits) reader(r io.Reader) io.Reader {
+	if l.bytes <= 0 {
+		return r
+	}
+	return &limit_reader{
+		r:	r,
+		l:	l,
+	}
+}
+
+//	Wrap decompressed workbook part with the byte limit and the conversion checks
+func (l *limits) read_closer(rc io.ReadCloser) io.ReadCloser {
+	return limit_read_closer{&convert_reader{l.reader(rc), l}, rc}
+}
+
+func (lr *limit_reader) Read(p []byte) (int, error){
+	n, err := lr.r.Read(p)
+	lr.n += int64(n)
+	if lr.n > lr.l.bytes {
+		return n, lr.l.bytes_error()
+	}
+	return n, err
+}
+
+func (cr *convert_reader) Read(p []byte) (int, error){
+	if err := cr.l.converting(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+//	Check record number (1-based count of records read) and record size
+func (l *limits) record(count int, record []string, line int) *Error {
+	if l.rows > 0 && count > l.rows {
+		return new_error(ErrMaxRows, fmt.Sprintf("Max rows exceeded: %d", l.rows), nil).at(line, 0)
+	}
+	if l.cols > 0 && len(record) > l.cols {
+		return new_error(ErrMaxCols, fmt.Sprintf("Max columns exceeded: %d", l.cols), nil).at(line, l.cols + 1)
+	}
+	if l.cell > 0 {
+		for c, value := range record {
+			if len(value) > l.cell {
+				return new_error(ErrMaxCell, fmt.Sprintf("Max cell length exceeded: %d", l.cell), nil).at(line, c + 1)
+			}
+		}
+	}
+	return nil
+}
+
+//	Check workbook cell while converting (0-based row and column)
+func (l *limits) sheet_cell(row, col int, value string) error {
+	if l.rows > 0 && row >= l.rows {
+		return new_error(ErrMaxRows, fmt.Sprintf("Max rows exceeded: %d", l.rows), nil).at(row + 1, 0)
+	}
+	if l.cols > 0 && col >= l.cols {
+		return new_error(ErrMaxCols, fmt.Sprintf("Max columns exceeded: %d", l.cols), nil).at(row + 1, col + 1)
+	}
+	if l.cell > 0 && len(value) > l.cell {
+		return new_error(ErrMaxCell, fmt.Sprintf("Max cell length exceeded: %d", l.cell), nil).at(row + 1, col + 1)
+	}
+	if err := l.converting(); err != nil {
+		return err
+	}
+	return nil
+}
+
+//	Check conversion deadline and context
+func (l *limits) converting() *Error {
+	if !l.deadline.IsZero() && time.Now().After(l.deadline) {
+		return new_error(ErrMaxConvertTime, fmt.Sprintf("Max conversion time exceeded: %s", l.convert), nil)
+	}
+	return l.canceled()
+}
+
+//	Check conversion deadline and context every cancel_interval iterations of a loop
+func (l *limits) converting_at(i int) error {
+	if i % cancel_interval != 0 {
+		return nil
+	}
+	if err := l.converting(); err != nil {
+		return err
+	}
+	return nil
+}
+
+//	Context error wrapped in Error (the context error is kept for errors.Is)
+func (l *limits) canceled() *Error {
+	if l.ctx == nil {
+		return nil
+	}
+	if err := l.ctx.Err(); err != nil {
+		return new_error(ErrCanceled, "Parsing canceled: "+err.Error(), err)
+	}
+	return nil
+}
+
+//	Start conversion timer
+func (l *limits) start(){
+	if l.convert > 0 {
+		l.deadline = time.Now().Add(l.convert)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,155 @@
+package csv
+
+import (
+	"os"
+	"fmt"
+	"time"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"path/filepath"
+)
+
+func Test_limits(t *testing.T){
+	input := "head1,head2\ntest1,test2\ntest3,test4\n" + strings.Repeat("x", 20) + ",y\ntest5,test6,test7"
+	tests := []struct{
+		reader	func() *Reader
+		is		error
+		line	int
+		column	int
+	}{{
+		reader:	func() *Reader {
+			return NewReader("").Max_bytes(20)
+		},
+		is:		ErrMaxBytes,
+	},{
+		reader:	func() *Reader {
+			return NewReader("").Max_rows(2)
+		},
+		is:		ErrMaxRows,
+		line:	3,
+	},{
+		reader:	func() *Reader {
+			return NewReader("").Max_cols(2)
+		},
+		is:		ErrMaxCols,
+		line:	5,
+		column:	3,
+	},{
+		reader:	func() *Reader {
+			return NewReader("").Max_cell(10)
+		},
+		is:		ErrMaxCell,
+		line:	4,
+		column:	1,
+	}}
+	for i, tt := range tests {
+		_, err := tt.reader().Bytes([]byte(input), "")
+		verify_limit(t, fmt.Sprintf("bytes %d", i), err, tt.is, tt.line, tt.column)
+		
+		//	Streaming stops at the same row
+		for _, err = range tt.reader().Rows(strings.NewReader(input)) {
+			if err != nil {
+				break
+			}
+		}
+		verify_limit(t, fmt.Sprintf("stream %d", i), err, tt.is, tt.line, tt.column)
+	}
+	
+	file := filepath.Join(t.TempDir(), "upload.csv")
+	if err := os.WriteFile(file, []byte(input), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	_, err := NewReader("").Max_bytes(20).File(file, "")
+	verify_limit(t, "file", err, ErrMaxBytes, 0, 0)
+	
+	if _, err := NewReader("").Remove_overflow_cols().Max_bytes(int64(len(input))).Max_rows(5).Max_cols(3).Max_cell(20).File(file, ""); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func Test_limits_workbook(t *testing.T){
+	var rows strings.Builder
+	for i := 1; i <= 200; i++ {
+		fmt.Fprintf(&rows, `<row r="%d"><c r="A%d" t="inlineStr"><is><t>test</t></is></c><c r="B%d"><v>%d</v></c></row>`, i, i, i, i)
+	}
+	b := test_xlsx(map[string]string{
+		"Data": rows.String(),
+	})
+	
+	_, err := NewReader("").Optional_header().Max_rows(100).Bytes(b, MIME_XLXS)
+	verify_limit(t, "rows", err, ErrMaxRows, 101, 0)
+	
+	_, err = NewReader("").Optional_header().Max_cols(1).Bytes(b, MIME_XLXS)
+	verify_limit(t, "cols", err, ErrMaxCols, 1, 2)
+	
+	//	Decompressed sheet is larger than the archive
+	_, err = NewReader("").Optional_header().Max_bytes(int64(len(b))).Bytes(b, MIME_XLXS)
+	verify_limit(t, "decompressed", err, ErrMaxBytes, 0, 0)
+	
+	_, err = NewReader("").Optional_header().Max_convert_time(time.Nanosecond).Bytes(b, MIME_XLXS)
+	verify_limit(t, "convert time", err, ErrMaxConvertTime, 0, 0)
+	
+	//	Deadline is checked while opening workbooks (sheets without cells would fail as empty)
+	for mimetype, b := range map[string][]byte{
+		MIME_XLS:	test_xls(nil, []string{"test"}),
+		MIME_XLXS:	test_xlsx(map[string]string{"Data": ""}),
+		MIME_ODS:	test_ods(map[string]string{"Data": ""}),
+	} {
+		_, err = NewReader("").Max_convert_time(time.Nanosecond).Bytes(b, mimetype)
+		verify_limit(t, "open "+mimetype, err, ErrMaxConvertTime, 0, 0)
+	}
+	
+	//	Deadline is checked while sanitizing converted rows
+	r := NewReader("").Max_convert_time(time.Nanosecond)
+	r.limits.start()
+	time.Sleep(time.Millisecond)
+	_, err = r.read_sheet(test_workbook{{"head1", "head2"}}, "Data", 0)
+	verify_limit(t, "sanitize", err, ErrMaxConvertTime, 0, 0)
+}
+
+func Test_context(t *testing.T){
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	
+	r := NewReader("")
+	_, err := r.BytesContext(ctx, []byte("head1,head2\ntest1,test2"), "")
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected canceled error, got %v", err)
+	}
+	if entries := r.Log_entries(); entries[len(entries)-1].Code != ErrCanceled.Code() {
+		t.Fatalf("Expected canceled log entry, got %+v", entries)
+	}
+	
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	_, err = NewReader("").FileContext(ctx, "missing.csv", "")
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline error, got %v", err)
+	}
+	
+	if _, err := NewReader("").BytesContext(context.Background(), []byte("head1,head2\ntest1,test2"), ""); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func verify_limit(t *testing.T, name string, err, is error, line, column int){
+	if !errors.Is(err, is) {
+		t.Fatalf("%s: expected %v, got %v", name, is, err)
+	}
+	var cerr *Error
+	if !errors.As(err, &cerr) || cerr.Line != line || cerr.Column != column {
+		t.Fatalf("%s: expected position %d:%d, got %+v", name, line, column, err)
+	}
+}
+
+type test_workbook [][]string
+
+func (w test_workbook) sheet_names() []string {
+	return []string{"Data"}
+}
+
+func (w test_workbook) rows(i int) ([][]string, error){
+	return w, nil
+}
\ No newline at end of file
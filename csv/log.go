@@ -0,0 +1,89 @@
+package csv
+
+const (
+	SEVERITY_INFO			= "info"
+	SEVERITY_WARNING		= "warning"
+	SEVERITY_ERROR			= "error"
+	
+	LOG_OPTIONS				= "options"
+	LOG_FORMAT				= "format"
+	LOG_CHARSET				= "charset"
+	LOG_LINE_ENDING			= "line_ending"
+	LOG_SEPARATOR			= "separator"
+	LOG_SHEETS				= "sheets"
+	LOG_SHEET				= "sheet"
+	LOG_HEADER				= "header"
+	LOG_ROWS				= "rows"
+	LOG_ROW_FILLED			= "row_filled"
+	LOG_ROW_REJECTED		= "row_rejected"
+	LOG_OVERFLOW_REMOVED	= "overflow_removed"
+	LOG_COLUMN_REMOVED		= "column_removed"
+	LOG_NON_PRINTABLE		= "non_printable"
+	LOG_VALUES_REPLACED		= "values_replaced"
+)
+
+type (
+	Log []Log_entry
+	
+	//	Log event (line, column and count are only set when relevant, errors use the Error code)
+	Log_entry struct {
+		Code		string	`json:"code"`
+		Severity	string	`json:"severity"`
+		Message		string	`json:"message"`
+		Line		*int	`json:"line,omitempty"`
+		Column		*int	`json:"column,omitempty"`
+		Count		*int	`json:"count,omitempty"`
+	}
+)
+
+//	Render log as messages
+func (l Log) Strings() []string {
+	s := make([]string, len(l))
+	for i, e := range l {
+		s[i] = e.Message
+	}
+	return s
+}
+
+func (e Log_entry) String() string {
+	return e.Message
+}
+
+func log_info(code, message string) Log_entry {
+	return Log_entry{
+		Code:		code,
+		Severity:	SEVERITY_INFO,
+		Message:	message,
+	}
+}
+
+func log_warning(code, message string) Log_entry {
+	return Log_entry{
+		Code:		code,
+		Severity:	SEVERITY_WARNING,
+		Message:	message,
+	}
+}
+
+func log_error(code, message string) Log_entry {
+	return Log_entry{
+		Code:		code,
+		Severity:	SEVERITY_ERROR,
+		Message:	message,
+	}
+}
+
+func (e Log_entry) line(i int) Log_entry {
+	e.Line = new(i)
+	return e
+}
+
+func (e Log_entry) column(i int) Log_entry {
+	e.Column = new(i)
+	return e
+}
+
+func (e Log_entry) count(i int) Log_entry {
+	e.Count = new(i)
+	return e
+}
\ No newline at end of file
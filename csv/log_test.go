@@ -0,0 +1,58 @@
+package csv
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"path/filepath"
+	"encoding/json"
+)
+
+func Test_log(t *testing.T){
+	dir := t.TempDir()
+	r := NewReader(dir)
+	if _, err := r.Bytes([]byte("head1;head2;head3\ntest1;test2\ntest1;test2;test3"), ""); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	
+	var filled *Log_entry
+	for _, e := range r.Log_entries() {
+		if e.Code == LOG_ROW_FILLED {
+			filled = &e
+		}
+	}
+	if filled == nil || filled.Severity != SEVERITY_WARNING || filled.Line == nil || *filled.Line != 2 || filled.Message != "Fill empty columns line: 2" {
+		t.Fatalf("Unexpected entry: %+v", filled)
+	}
+	if !slices.Equal(r.Log(), r.Log_entries().Strings()) {
+		t.Fatal("Want log messages to match entries")
+	}
+	
+	file := filepath.Join(dir, "src", "upload.csv")
+	if err := r.Write_src(file); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	b, err := os.ReadFile(file+".log.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var entries Log
+	if err := json.Unmarshal(b, &entries); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(entries) != len(r.Log_entries()) || entries[len(entries)-1].Code != LOG_ROWS || *entries[len(entries)-1].Count != 2 {
+		t.Fatalf("Unexpected entries: %s", b)
+	}
+	
+	//	Columns are 1-based as in errors
+	r = NewReader(dir).Remove_empty_cols()
+	if _, err := r.Bytes([]byte("head1;;head3\ntest1;;test3"), ""); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	i := slices.IndexFunc(r.Log_entries(), func(e Log_entry) bool {
+		return e.Code == LOG_COLUMN_REMOVED
+	})
+	if i == -1 || *r.Log_entries()[i].Column != 2 || r.Log_entries()[i].Message != "Remove empty column: 2" {
+		t.Fatalf("Unexpected entries: %+v", r.Log_entries())
+	}
+}
\ No newline at end of file
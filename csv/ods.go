@@ -0,0 +1,290 @@
+package csv
+
+import (
+	"io"
+	"fmt"
+	"bytes"
+	"strconv"
+	"strings"
+	"archive/zip"
+	"encoding/xml"
+)
+
+const (
+	ods_ns_office	= "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	ods_ns_table	= "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	ods_ns_text		= "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+type (
+	ods struct {
+		lim		*limits
+		content	*zip.File
+		sheets	[]string
+	}
+	
+	ods_cell struct {
+		value		strings.Builder
+		paragraphs	int
+		text		bool
+		annotation	int
+	}
+)
+
+func open_ods(b []byte, lim *limits) (*ods, error){
+	z, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ODS archive: %w", err)
+	}
+	
+	o := &ods{
+		lim:	lim,
+	}
+	for _, f := range z.File {
+		if f.Name == "content.xml" {
+			o.content = f
+			break
+		}
+	}
+	if o.content == nil {
+		return nil, fmt.Errorf("ODS file missing: content.xml")
+	}
+	
+	err = o.tables(func(dec *xml.Decoder, t xml.StartElement) (bool, error){
+		o.sheets = append(o.sheets, attr_ns(t, ods_ns_table, "name"))
+		return true, dec.Skip()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *ods) sheet_names() []string {
+	return o.sheets
+}
+
+func (o *ods) rows(i int) ([][]string, error){
+	var lines [][]string
+	n := 0
+	err := o.tables(func(dec *xml.Decoder, t xml.StartElement) (bool, error){
+		if n != i {
+			n++
+			return true, dec.Skip()
+		}
+		
+		var err error
+		lines, err = o.table(dec)
+		return false, err
+	})
+	return lines, err
+}
+
+//	Read table rows (repeated empty rows and cells are only kept when followed by values)
+func (o *ods) table(dec *xml.Decoder) ([][]string, error){
+	var (
+		lines		[][]string
+		line		[]string
+		cells		int
+		empty_rows	int
+		empty_cells	int
+		row_repeat	int
+		cell_repeat	int
+		cell		*ods_cell
+		cell_attrs	xml.StartElement
+	)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ODS content: %w", err)
+		}
+		
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Space == ods_ns_table && t.Name.Local == "table-row":
+				line		= nil
+				empty_cells	= 0
+				row_repeat	= ods_repeat(t, "number-rows-repeated")
+				if row_repeat > sheet_rows {
+					return nil, fmt.Errorf("Invalid ODS row repeat: %d", row_repeat)
+				}
+			case t.Name.Space == ods_ns_table && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				cell		= &ods_cell{}
+				cell_attrs	= t
+				cell_repeat	= ods_repeat(t, "number-columns-repeated")
+				if cell_repeat > sheet_cols {
+					return nil, fmt.Errorf("Invalid ODS column repeat: %d", cell_repeat)
+				}
+			//	Cell content only
+			case cell == nil:
+			case t.Name.Space == ods_ns_office && t.Name.Local == "annotation":
+				cell.annotation++
+			case cell.annotation != 0:
+			case t.Name.Space == ods_ns_text && t.Name.Local == "p":
+				if cell.paragraphs != 0 {
+					cell.value.WriteByte('\n')
+				}
+				cell.paragraphs++
+				cell.text = true
+			case t.Name.Space == ods_ns_text && t.Name.Local == "s":
+				c, err := strconv.Atoi(attr_ns(t, ods_ns_text, "c"))
+				if err != nil || c < 1 {
+					c = 1
+				}
+				cell.value.WriteString(strings.Repeat(" ", min(c, 1024)))
+			case t.Name.Space == ods_ns_text && t.Name.Local == "tab":
+				cell.value.WriteByte('\t')
+			case t.Name.Space == ods_ns_text && t.Name.Local == "line-break":
+				cell.value.WriteByte('\n')
+			}
+		case xml.CharData:
+			if cell != nil && cell.text && cell.annotation == 0 {
+				cell.value.Write(t)
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Space == ods_ns_table && t.Name.Local == "table":
+				return lines, nil
+			case t.Name.Space == ods_ns_table && t.Name.Local == "table-row":
+				if len(line) == 0 {
+					empty_rows += row_repeat
+					continue
+				}
+				//	Repeated rows are only materialized within the sheet limits
+				if len(lines) + empty_rows + row_repeat > sheet_rows {
+					return nil, fmt.Errorf("Invalid ODS row repeat: %d rows exceeded", sheet_rows)
+				}
+				cells += row_repeat * len(line)
+				if cells > sheet_cells {
+					return nil, fmt.Errorf("Invalid ODS row repeat: %d cells exceeded", sheet_cells)
+				}
+				if err := o.lim.sheet_cell(len(lines) + empty_rows + row_repeat - 1, len(line) - 1, ""); err != nil {
+					return nil, err
+				}
+				for ; empty_rows > 0; empty_rows-- {
+					lines = append(lines, nil)
+				}
+				for i := range row_repeat {
+					if err := o.lim.converting_at(i); err != nil {
+						return nil, err
+					}
+					lines = append(lines, append([]string{}, line...))
+				}
+			case t.Name.Space == ods_ns_table && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				value := ods_value(cell_attrs, cell.value.String())
+				cell = nil
+				if value == "" {
+					empty_cells += cell_repeat
+					continue
+				}
+				if len(line) + empty_cells + cell_repeat > sheet_cols {
+					return nil, fmt.Errorf("Invalid ODS column repeat: %d columns exceeded", sheet_cols)
+				}
+				if err := o.lim.sheet_cell(len(lines) + empty_rows, len(line) + empty_cells + cell_repeat - 1, value); err != nil {
+					return nil, err
+				}
+				for ; empty_cells > 0; empty_cells-- {
+					line = append(line, "")
+				}
+				for i := range cell_repeat {
+					if err := o.lim.converting_at(i); err != nil {
+						return nil, err
+					}
+					line = append(line, value)
+				}
+			case cell == nil:
+			case t.Name.Space == ods_ns_office && t.Name.Local == "annotation":
+				cell.annotation--
+			case t.Name.Space == ods_ns_text && t.Name.Local == "p":
+				cell.text = false
+			}
+		}
+	}
+}
+
+//	Iterate tables in content (callback returns false to stop)
+func (o *ods) tables(fn func(*xml.Decoder, xml.StartElement) (bool, error)) error {
+	rc, err := o.content.Open()
+	if err != nil {
+		return fmt.Errorf("Unable to open ODS content: %w", err)
+	}
+	f := o.lim.read_closer(rc)
+	defer f.Close()
+	
+	dec := xml.NewDecoder(f)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid ODS content: %w", err)
+		}
+		
+		t, ok := tok.(xml.StartElement)
+		if !ok || t.Name.Space != ods_ns_table || t.Name.Local != "table" {
+			continue
+		}
+		next, err := fn(dec, t)
+		if err != nil || !next {
+			return err
+		}
+	}
+}
+
+//	Get typed cell value
+func ods_value(t xml.StartElement, text string) string {
+	switch attr_ns(t, ods_ns_office, "value-type") {
+	case "float", "percentage", "currency":
+		if f, err := strconv.ParseFloat(attr_ns(t, ods_ns_office, "value"), 64); err == nil {
+			return excel_number(f)
+		}
+	case "date":
+		value := attr_ns(t, ods_ns_office, "date-value")
+		if i := strings.IndexByte(value, '.'); i != -1 {
+			value = value[:i]
+		}
+		return strings.TrimSuffix(strings.Replace(value, "T", " ", 1), " 00:00:00")
+	case "time":
+		if value := ods_time(attr_ns(t, ods_ns_office, "time-value")); value != "" {
+			return value
+		}
+	case "boolean":
+		if attr_ns(t, ods_ns_office, "boolean-value") == "true" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "string":
+		if value := attr_ns(t, ods_ns_office, "string-value"); value != "" {
+			return value
+		}
+	}
+	return text
+}
+
+//	Convert ISO 8601 duration (PT12H30M00S) to time
+func ods_time(d string) string {
+	var h, m, s float64
+	if _, err := fmt.Sscanf(d, "PT%gH%gM%gS", &h, &m, &s); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", int(h), int(m), int(s))
+}
+
+func ods_repeat(t xml.StartElement, name string) int {
+	n, err := strconv.Atoi(attr_ns(t, ods_ns_table, name))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+func attr_ns(t xml.StartElement, space, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Space == space && a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
\ No newline at end of file
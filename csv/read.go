@@ -1,625 +1,909 @@
-package csv
-
-import (
-	"os"
-	"fmt"
-	"slices"
-	"bytes"
-	"regexp"
-	"strings"
-	"strconv"
-	"unicode/utf8"
-	"encoding/csv"
-	"path/filepath"
-	"github.com/go-errors/errors"
-	"golang.org/x/sys/unix"
-	"github.com/clarkk/go-fmt/sanitize"
-	"github.com/clarkk/go-util/cmd"
-	"github.com/clarkk/go-util/futil"
-)
-
-const (
-	BOM_UTF8		= "\xEF\xBB\xBF"
-	BOM_UTF16LE		= "\xFF\xFE"
-	BOM_UTF16BE		= "\xFE\xFF"
-	
-	MIME_XLS		= "application/vnd.ms-excel"
-	MIME_XLXS		= "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
-	
-	opt_col_integrity			= "col_integrity"
-	opt_remove_empty_cols		= "remove_empty_cols"
-	opt_remove_overflow_cols	= "remove_overflow_cols"
-	opt_optional_header			= "optional_header"
-	opt_ignore_header			= "ignore_header"
-)
-
-var (
-	separators = []rune{
-		',',
-		';',
-		'\t',
-	}
-	
-	re_col_heading = regexp.MustCompile(`[^\pL\d]`)
-)
-
-type (
-	Reader struct {
-		options			map[string]bool
-		
-		tmp_dir			string
-		
-		src				[]byte
-		src_converted	[]byte
-		src_encoded		[]byte
-		
-		separator		rune
-		checked_header	bool
-		out 			Rows
-		out_header		[]string
-		
-		non_printable	string
-		
-		log 			Log
-	}
-	
-	Header 		[]string
-	Rows		[]row
-	Log 		[]string
-	
-	table struct {
-		Header 	Header
-		Rows	Rows
-	}
-	
-	row struct {
-		Line	int			`json:"line"`
-		Row		[]string	`json:"row"`
-	}
-)
-
-func NewReader(tmp_dir string) *Reader {
-	return &Reader{
-		options: map[string]bool{
-			opt_col_integrity:			false,
-			opt_remove_empty_cols:		false,
-			opt_remove_overflow_cols:	false,
-			opt_optional_header:		false,
-			opt_ignore_header:			false,
-		},
-		tmp_dir: tmp_dir,
-	}
-}
-
-//	Parse file
-func (r *Reader) File(file, mimetype string) (table, error){
-	var err error
-	r.src, err = os.ReadFile(file)
-	if err != nil {
-		return table{}, fmt.Errorf("Unable read CSV file: %w", err)
-	}
-	return r.parse(mimetype)
-}
-
-//	Parse bytes
-func (r *Reader) Bytes(b []byte, mimetype string) (table, error){
-	r.src = b
-	return r.parse(mimetype)
-}
-
-//	Write source to file
-func (r *Reader) Write_src(file string) error {
-	dir := filepath.Dir(file)
-	if _, err := os.Stat(dir); err != nil {
-		if !errors.Is(err, os.ErrNotExist) {
-			return fmt.Errorf("Unable to read directory stat: %w", err)
-		}
-		if err := os.MkdirAll(dir, futil.CHMOD_RWX_OWNER); err != nil {
-			return fmt.Errorf("Unable to create directory: %w", err)
-		}
-	}
-	if err := unix.Access(r.tmp_dir, unix.W_OK); err != nil {
-		return fmt.Errorf("Directory not writeable: %w", err)
-	}
-	if err := os.WriteFile(file, r.src, futil.CHMOD_RW_OWNER); err != nil {
-		return fmt.Errorf("Unable to write file: %w", err)
-	}
-	
-	log := strings.Join(r.Log(), "\r\n")
-	if err := os.WriteFile(file+".log", []byte(log), 0664); err != nil {
-		return fmt.Errorf("Unable to write file: %w", err)
-	}
-	
-	return nil
-}
-
-//	Ensure colum integrity (same quantity of columns in each line)
-func (r *Reader) Col_integrity() *Reader {
-	r.options[opt_col_integrity] = true
-	return r
-}
-
-//	Remove empty colums
-func (r *Reader) Remove_empty_cols() *Reader {
-	r.options[opt_remove_empty_cols] = true
-	return r
-}
-
-//	Remove overflow colums
-func (r *Reader) Remove_overflow_cols() *Reader {
-	r.options[opt_remove_overflow_cols] = true
-	return r
-}
-
-//	Optional column header
-func (r *Reader) Optional_header() *Reader {
-	r.options[opt_optional_header] = true
-	return r
-}
-
-//	Ignore column header
-func (r *Reader) Ignore_header() *Reader {
-	r.options[opt_ignore_header] = true
-	return r
-}
-
-func (r *Reader) Log() []string {
-	return r.log
-}
-
-func (r *Reader) parse(mimetype string) (table, error){
-	r.log_options()
-	
-	if r.options[opt_optional_header] && r.options[opt_ignore_header] {
-		return table{}, &Error{"Options 'optional_header' and 'ignore_header' can not be used in conjunction", nil}
-	}
-	
-	if r.options[opt_remove_overflow_cols] && r.options[opt_ignore_header] {
-		return table{}, &Error{"Options 'remove_overflow_cols' and 'ignore_header' can not be used in conjunction", nil}
-	}
-	
-	if r.options[opt_remove_overflow_cols] && r.options[opt_col_integrity] {
-		return table{}, &Error{"Options 'remove_overflow_cols' and 'col_integrity' can not be used in conjunction", nil}
-	}
-	
-	if mimetype == MIME_XLS || mimetype == MIME_XLXS {
-		if err := r.convert_xls(); err != nil {
-			return table{}, err
-		}
-	}
-	
-	if err := r.encoding(); err != nil {
-		r.log_append(err.Error())
-		return table{}, &Error{err.Error(), nil}
-	}
-	
-	read := csv.NewReader(bytes.NewBuffer(r.src_encoded))
-	read.FieldsPerRecord	= -1
-	read.Comma				= r.separator
-	
-	lines, err := read.ReadAll()
-	if err != nil {
-		if r.non_printable != "" {
-			r.log_non_printable()
-			return table{}, &Error{"Invalid CSV file encoding", nil}
-		}
-		r.log_append("Unable to parse CSV: "+err.Error())
-		return table{}, &Error{"Unable to parse CSV: "+err.Error(), err}
-	}
-	r.parse_lines(lines)
-	
-	if err := r.empty_rows_error(); err != nil {
-		return table{}, err
-	}
-	
-	cols		:= r.cols()
-	cols_max	:= slices.Max(cols)
-	
-	if err := r.one_col_error(cols_max); err != nil {
-		return table{}, err
-	}
-	
-	//	Remove empty columns before check_header()
-	if r.options[opt_remove_empty_cols] {
-		r.remove_empty_cols()
-		
-		cols		= r.cols()
-		cols_max	= slices.Max(cols)
-	}
-	
-	if !r.options[opt_ignore_header] {
-		if r.options[opt_remove_overflow_cols] {
-			if r.check_header(false) == nil {
-				if err := r.empty_rows_error(); err != nil {
-					return table{}, err
-				}
-				
-				if r.options[opt_remove_empty_cols] {
-					r.remove_empty_cols()
-					
-					cols		= r.cols()
-					cols_max	= slices.Max(cols)
-				}
-				
-				r.remove_overflow_cols()
-				
-				cols		= r.cols()
-				cols_max	= slices.Max(cols)
-			}
-		}
-		
-		if len(r.out_header) == 0 && cols[0] < cols_max {
-			r.log_append("CSV has too few column headers")
-			return table{}, &Error{"CSV has too few column headers", nil}
-		}
-	}
-	
-	if r.options[opt_col_integrity] {
-		if cols_max != slices.Min(cols) {
-			r.log_append("Columns in CSV not equal")
-			return table{}, &Error{"Columns in CSV not equal", nil}
-		}
-	} else {
-		r.fill_empty_cols(cols_max)
-	}
-	
-	if !r.checked_header {
-		//	Optional column header
-		if r.options[opt_optional_header] {
-			if r.check_header(false) == nil {
-				if err := r.empty_rows_error(); err != nil {
-					return table{}, err
-				}
-				
-				if r.options[opt_remove_empty_cols] {
-					r.remove_empty_cols()
-					
-					cols		= r.cols()
-					cols_max	= slices.Max(cols)
-				}
-			}
-		//	Require column header
-		} else if !r.options[opt_ignore_header] {
-			if err := r.check_header(true); err != nil {
-				return table{}, err
-			}
-			
-			if err := r.empty_rows_error(); err != nil {
-				return table{}, err
-			}
-			
-			if r.options[opt_remove_empty_cols] {
-				r.remove_empty_cols()
-				
-				cols		= r.cols()
-				cols_max	= slices.Max(cols)
-			}
-		}
-	}
-	
-	if err := r.one_col_error(cols_max); err != nil {
-		return table{}, err
-	}
-	
-	if r.non_printable != "" {
-		r.strip_non_printable()
-	}
-	
-	r.log_append(fmt.Sprintf("Rows found: %d", len(r.out)))
-	return table{
-		r.out_header,
-		r.out,
-	}, nil
-}
-
-func (r *Reader) encoding() error {
-	var src []byte
-	if len(r.src_converted) != 0 {
-		src = r.src_converted
-	} else {
-		src = r.src
-	}
-	
-	//	Detect and strip UTF8 BOM
-	if bytes.HasPrefix(src, []byte(BOM_UTF8)) {
-		s := string(src[len(BOM_UTF8):])
-		s = sanitize.Filter_utf8mb3(s)
-		s = sanitize.Trim(s, true)
-		r.log_append("UTF8 BOM found")
-		return r.src_encoding(s)
-	}
-	
-	s := string(src)
-	
-	//	Valid UTF8
-	if utf8.Valid(src) {
-		s = sanitize.Filter_utf8mb3(s)
-		s = sanitize.Trim(s, true)
-		r.log_append("UTF8 validated")
-		return r.src_encoding(s)
-	}
-	
-	//	Encode UTF8
-	out := make([]byte, len(s) * utf8.UTFMax)
-	n := 0
-	for _, r := range []byte(s) {
-		n += utf8.EncodeRune(out[n:], rune(r))
-	}
-	s = string(out[:n])
-	s = sanitize.Filter_utf8mb3(s)
-	s = sanitize.Trim(s, true)
-	r.log_append("UTF8 encoded")
-	return r.src_encoding(s)
-}
-
-func (r *Reader) convert_xls() error {
-	if r.tmp_dir == "" {
-		return fmt.Errorf("Temp directory not defined")
-	}
-	
-	if err := unix.Access(r.tmp_dir, unix.W_OK); err != nil {
-		return fmt.Errorf("Temp directory not writeable: %w", err)
-	}
-	
-	f, err := os.CreateTemp(r.tmp_dir, "xls")
-	if err != nil {
-		return fmt.Errorf("Unable to create temp xls file: %w", err)
-	}
-	file_name := f.Name()
-	defer os.Remove(file_name)
-	
-	_, err = f.Write(r.src)
-	if err != nil {
-		return fmt.Errorf("Unable to write temp xls file: %w", err)
-	}
-	
-	file_name_csv := file_name+".csv"
-	c := cmd.Command{}
-	if err := c.Run("ssconvert "+file_name+" "+file_name_csv); err != nil {
-		r.log_append("Unable to convert XLS to CSV")
-		return &Error{"Unable to convert XLS to CSV", err}
-	}
-	defer os.Remove(file_name_csv)
-	
-	if err := r.src_convert_file(file_name_csv); err != nil {
-		return err
-	}
-	
-	r.log_append("XLS converted to CSV")
-	return nil
-}
-
-func (r *Reader) strip_non_printable(){
-	c := 0
-	for i, value := range r.out_header {
-		s := sanitize.Strip_non_printable(value)
-		if s != value {
-			r.out_header[i] = s
-			c++
-		}
-	}
-	for i := range r.out {
-		for j, value := range r.out[i].Row {
-			s := sanitize.Strip_non_printable(value)
-			if s != value {
-				r.out[i].Row[j] = s
-				c++
-			}
-		}
-	}
-	r.log_append(fmt.Sprintf("Values replaced (non-printable): %d", c))
-}
-
-func (r *Reader) parse_lines(lines [][]string){
-	for l, line := range lines {
-		empty_line := true
-		
-		for c, col := range line {
-			col = strings.TrimSpace(col)
-			
-			if col != "" {
-				empty_line = false
-			}
-			
-			line[c] = col
-		}
-		
-		//	Remove empty rows
-		if !empty_line {
-			r.out = append(r.out, row{
-				l,
-				line,
-			})
-		}
-	}
-}
-
-func (r *Reader) remove_empty_cols(){
-	cols_max	:= slices.Max(r.cols())
-	cols		:= make([]bool, cols_max)
-	for _, row := range r.out {
-		for i, value := range row.Row {
-			if value != "" {
-				cols[i] = true
-			}
-		}
-	}
-	
-	for c := cols_max - 1; c >= 0; c-- {
-		if cols[c] {
-			continue
-		}
-		
-		r.log_append(fmt.Sprintf("Remove empty column: %d", c))
-		if len(r.out_header) > c {
-			r.out_header = append(r.out_header[:c], r.out_header[c+1:]...)
-		}
-		for i := range r.out {
-			if len(r.out[i].Row) > c {
-				r.out[i].Row = append(r.out[i].Row[:c], r.out[i].Row[c+1:]...)
-			}
-		}
-	}
-}
-
-func (r *Reader) remove_overflow_cols(){
-	cols_max := len(r.out_header)
-	for i, row := range r.out {
-		if len(row.Row) > cols_max {
-			r.log_append(fmt.Sprintf("Remove overflow columns row: %d", i))
-			r.out[i].Row = r.out[i].Row[:cols_max]
-		}
-	}
-}
-
-func (r *Reader) check_header(error_log bool) error {
-	r.checked_header	= true
-	has_heading			:= true
-	
-	first_row := r.out[0].Row
-	for _, value := range first_row {
-		if value == "" {
-			if error_log {
-				r.log_append("Column headers cannot be empty")
-			}
-			return &Error{"Column headers cannot be empty", nil}
-		}
-		
-		value = re_col_heading.ReplaceAllString(value, "")
-		if _, err := strconv.Atoi(value); err == nil {
-			has_heading	= false
-		}
-	}
-	
-	if !has_heading {
-		if error_log {
-			r.log_append("Column headers in CSV required")
-		}
-		return &Error{"Column headers in CSV required", nil}
-	} else {
-		r.log_append("Column headers found")
-		r.out_header	= first_row
-		r.out			= r.out[1:]
-	}
-	return nil
-}
-
-func (r *Reader) fill_empty_cols(cols_max int){
-	for t, row := range r.out {
-		l := len(row.Row)
-		if l != cols_max {
-			r.log_append(fmt.Sprintf("Fill empty columns row: %d", t))
-			for i := 0; i < cols_max - l; i++ {
-				r.out[t].Row = append(r.out[t].Row, "")
-			}
-		}
-	}
-}
-
-func (r *Reader) get_separator(s string) error {
-	if r.get_separator_lines(s) {
-		return nil
-	}
-	
-	c := newCount_sep()
-	for _, sep := range separators {
-		c.count_sep(sep, strings.Count(s, string(sep)))
-	}
-	
-	sep, err := c.get_sep()
-	if err != nil {
-		return err
-	}
-	
-	r.separator = sep
-	r.log_append("Separator: "+string(r.separator))
-	return nil
-}
-
-func (r *Reader) get_separator_lines(s string) bool {
-	c := newCount_sep()
-	for _, line := range strings.Split(s, "\n") {
-		if line == "" {
-			continue
-		}
-		for _, sep := range separators {
-			c.count_lines_sep(sep, strings.Count(line, string(sep)))
-		}
-	}
-	
-	sep, err := c.get_lines_sep()
-	if err != nil {
-		return false
-	}
-	
-	r.separator = sep
-	r.log_append("Separator (lines): "+string(r.separator))
-	return true
-}
-
-func (r *Reader) src_convert_file(file string) error {
-	var err error
-	r.src_converted, err = os.ReadFile(file)
-	if err != nil {
-		return fmt.Errorf("Unable to read temp csv file: %w", err)
-	}
-	return nil
-}
-
-func (r *Reader) src_encoding(s string) error {
-	r.src_encoded	= []byte(s)
-	r.non_printable = sanitize.Non_printable(s)
-	
-	if s == "" {
-		return fmt.Errorf("CSV empty")
-	}
-	
-	return r.get_separator(s)
-}
-
-func (r *Reader) cols() []int {
-	cols := make([]int, len(r.out))
-	for i, row := range r.out {
-		cols[i] = len(row.Row)
-	}
-	return cols
-}
-
-func (r *Reader) log_non_printable(){
-	len_total			:= len(r.src_encoded)
-	len_non_printable	:= len(r.non_printable)
-	percent				:= float32(len_non_printable) / float32(len_total) * 100
-	r.log_append(fmt.Sprintf("Non-printable chars found (%d / %d = %.2f%%): %s", len_non_printable, len_total, percent, r.non_printable))
-}
-
-func (r *Reader) log_options(){
-	var opts []string
-	for k, v := range r.options {
-		if v {
-			opts = append(opts, k)
-		}
-	}
-	if len(opts) != 0 {
-		r.log_append("Options: "+strings.Join(opts, ", "))
-	}
-}
-
-func (r *Reader) log_append(s string){
-	r.log = append(r.log, s)
-}
-
-func (r *Reader) empty_rows_error() error {
-	if len(r.out) == 0 {
-		r.log_append("CSV empty")
-		return &Error{"CSV empty", nil}
-	}
-	return nil
-}
-
-func (r *Reader) one_col_error(cols_max int) error {
-	if cols_max == 1 {
-		r.log_append("CSV must have more than one column")
-		return &Error{"CSV must have more than one column", nil}
-	}
-	return nil
+package csv
+
+import (
+	"io"
+	"os"
+	"context"
+	"fmt"
+	"slices"
+	"bytes"
+	"regexp"
+	"strings"
+	"strconv"
+	"unicode/utf8"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"github.com/go-errors/errors"
+	"golang.org/x/sys/unix"
+	"github.com/clarkk/go-fmt/sanitize"
+	"github.com/clarkk/go-util/futil"
+)
+
+const (
+	BOM_UTF8		= "\xEF\xBB\xBF"
+	BOM_UTF16LE		= "\xFF\xFE"
+	BOM_UTF16BE		= "\xFE\xFF"
+	
+	MIME_XLS		= "application/vnd.ms-excel"
+	MIME_XLXS		= "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	MIME_ODS		= "application/vnd.oasis.opendocument.spreadsheet"
+	MIME_HTML		= "text/html"
+	
+	charset_utf8				= "UTF8"
+	charset_latin1				= "Latin1"
+	charset_utf16le				= "UTF16LE"
+	charset_utf16be				= "UTF16BE"
+	
+	opt_col_integrity			= "col_integrity"
+	opt_remove_empty_cols		= "remove_empty_cols"
+	opt_remove_overflow_cols	= "remove_overflow_cols"
+	opt_optional_header			= "optional_header"
+	opt_ignore_header			= "ignore_header"
+	opt_auto_sheet				= "auto_sheet"
+	opt_lenient					= "lenient"
+)
+
+var (
+	separators = []rune{
+		',',
+		';',
+		'\t',
+	}
+	
+	re_col_heading = regexp.MustCompile(`[^\pL\d]`)
+)
+
+type (
+	Reader struct {
+		options			map[string]bool
+		
+		tmp_dir			string
+		
+		sheet			string
+		sheet_index		int
+		sheets			[]string
+		
+		src				[]byte
+		src_encoded		[]byte
+		src_lines		*line_index
+		
+		charset			string
+		charset_forced	string
+		separator		rune
+		sep_forced		rune
+		sep_extra		[]rune
+		sep_confidence	float64
+		line_ending		string
+		unbalanced		bool
+		checked_header	bool
+		out 			Rows
+		out_header		[]string
+		rejected		[]Rejected
+		
+		non_printable	string
+		
+		limits			limits
+		
+		log 			Log
+	}
+	
+	Header 		[]string
+	Rows		[]Row
+	
+	Table struct {
+		Header 		Header
+		Rows		Rows
+		Separator	rune
+		Rejected	[]Rejected
+	}
+	
+	//	Unparsable record quarantined in lenient mode
+	Rejected struct {
+		Line	int		`json:"line"`
+		Raw		[]byte	`json:"raw"`
+		Reason	string	`json:"reason"`
+	}
+	
+	//	Physical position of the row in the source
+	Row struct {
+		Line		int			`json:"line"`
+		Line_end	int			`json:"line_end"`
+		Offset		int64		`json:"offset"`
+		Row			[]string	`json:"row"`
+	}
+	
+	//	Tracks positions of the records read by encoding/csv
+	row_pos struct {
+		start_line	int
+		lines		*line_index
+	}
+)
+
+func NewReader(tmp_dir string) *Reader {
+	return &Reader{
+		options: map[string]bool{
+			opt_col_integrity:			false,
+			opt_remove_empty_cols:		false,
+			opt_remove_overflow_cols:	false,
+			opt_optional_header:		false,
+			opt_ignore_header:			false,
+			opt_auto_sheet:				false,
+			opt_lenient:				false,
+		},
+		tmp_dir: tmp_dir,
+	}
+}
+
+//	Parse file
+func (r *Reader) File(file, mimetype string) (Table, error){
+	return r.FileContext(context.Background(), file, mimetype)
+}
+
+//	Parse file until the context is canceled
+func (r *Reader) FileContext(ctx context.Context, file, mimetype string) (Table, error){
+	r.limits.ctx = ctx
+	if err := r.limits.canceled(); err != nil {
+		return Table{}, r.log_fail(err)
+	}
+	
+	f, err := os.Open(file)
+	if err != nil {
+		return Table{}, new_error(ErrRead, "Unable read CSV file", err)
+	}
+	defer f.Close()
+	
+	//	Size is checked before reading and while reading as it may change
+	if info, err := f.Stat(); err == nil {
+		if err := r.limits.size(info.Size()); err != nil {
+			return Table{}, r.log_fail(err)
+		}
+	}
+	if err := r.read_src(f); err != nil {
+		return Table{}, err
+	}
+	return r.parse(mimetype)
+}
+
+//	Read source with the byte limit
+func (r *Reader) read_src(src io.Reader) error {
+	b, err := io.ReadAll(r.limits.reader(src))
+	if err != nil {
+		var lerr *Error
+		if errors.As(err, &lerr) {
+			return r.log_fail(lerr)
+		}
+		return new_error(ErrRead, "Unable read CSV file", err)
+	}
+	r.src = b
+	return nil
+}
+
+//	Parse bytes
+func (r *Reader) Bytes(b []byte, mimetype string) (Table, error){
+	return r.BytesContext(context.Background(), b, mimetype)
+}
+
+//	Parse bytes until the context is canceled
+func (r *Reader) BytesContext(ctx context.Context, b []byte, mimetype string) (Table, error){
+	r.limits.ctx = ctx
+	if err := r.limits.size(int64(len(b))); err != nil {
+		return Table{}, r.log_fail(err)
+	}
+	r.src = b
+	return r.parse(mimetype)
+}
+
+//	Write source to file
+func (r *Reader) Write_src(file string) error {
+	dir := filepath.Dir(file)
+	if _, err := os.Stat(dir); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("Unable to read directory stat: %w", err)
+		}
+		if err := os.MkdirAll(dir, futil.CHMOD_RWX_OWNER); err != nil {
+			return fmt.Errorf("Unable to create directory: %w", err)
+		}
+	}
+	if err := unix.Access(r.tmp_dir, unix.W_OK); err != nil {
+		return fmt.Errorf("Directory not writeable: %w", err)
+	}
+	if err := os.WriteFile(file, r.src, futil.CHMOD_RW_OWNER); err != nil {
+		return fmt.Errorf("Unable to write file: %w", err)
+	}
+	
+	log := strings.Join(r.Log(), "\r\n")
+	if err := os.WriteFile(file+".log", []byte(log), 0664); err != nil {
+		return fmt.Errorf("Unable to write file: %w", err)
+	}
+	
+	entries, err := json.Marshal(r.log)
+	if err != nil {
+		return fmt.Errorf("Unable to encode log: %w", err)
+	}
+	if err := os.WriteFile(file+".log.json", entries, 0664); err != nil {
+		return fmt.Errorf("Unable to write file: %w", err)
+	}
+	
+	return nil
+}
+
+//	Ensure colum integrity (same quantity of columns in each line)
+func (r *Reader) Col_integrity() *Reader {
+	r.options[opt_col_integrity] = true
+	return r
+}
+
+//	Remove empty colums
+func (r *Reader) Remove_empty_cols() *Reader {
+	r.options[opt_remove_empty_cols] = true
+	return r
+}
+
+//	Remove overflow colums
+func (r *Reader) Remove_overflow_cols() *Reader {
+	r.options[opt_remove_overflow_cols] = true
+	return r
+}
+
+//	Skip unparsable records (e.g. stray quotes) and collect them in Table.Rejected instead of failing
+func (r *Reader) Lenient() *Reader {
+	r.options[opt_lenient] = true
+	return r
+}
+
+//	Optional column header
+func (r *Reader) Optional_header() *Reader {
+	r.options[opt_optional_header] = true
+	return r
+}
+
+//	Ignore column header
+func (r *Reader) Ignore_header() *Reader {
+	r.options[opt_ignore_header] = true
+	return r
+}
+
+//	Column header found by the last parse
+func (r *Reader) Header() Header {
+	return r.out_header
+}
+
+//	Force charset instead of detecting it (e.g. "windows-1252", "iso-8859-15", "cp865", "macintosh", "utf-16le")
+func (r *Reader) Charset(name string) *Reader {
+	r.charset_forced = name
+	return r
+}
+
+//	Force separator instead of detecting it
+func (r *Reader) Separator(sep rune) *Reader {
+	r.sep_forced = sep
+	return r
+}
+
+//	Add separator candidates to the detection
+func (r *Reader) Separator_candidates(seps ...rune) *Reader {
+	r.sep_extra = append(r.sep_extra, seps...)
+	return r
+}
+
+//	Select workbook sheet by name
+func (r *Reader) Sheet(name string) *Reader {
+	r.sheet = name
+	return r
+}
+
+//	Select workbook sheet by index
+func (r *Reader) Sheet_index(i int) *Reader {
+	r.sheet			= ""
+	r.sheet_index	= i
+	return r
+}
+
+//	Select first non-empty workbook sheet with column header
+func (r *Reader) Auto_sheet() *Reader {
+	r.options[opt_auto_sheet] = true
+	return r
+}
+
+//	Sheets found in workbook by the last parse
+func (r *Reader) Sheets() []string {
+	return r.sheets
+}
+
+//	Log messages
+func (r *Reader) Log() []string {
+	return r.log.Strings()
+}
+
+//	Log as typed entries
+func (r *Reader) Log_entries() Log {
+	return r.log
+}
+
+func (r *Reader) check_options() error {
+	if r.options[opt_optional_header] && r.options[opt_ignore_header] {
+		return new_error(ErrOptions, "Options 'optional_header' and 'ignore_header' can not be used in conjunction", nil)
+	}
+	
+	if r.options[opt_remove_overflow_cols] && r.options[opt_ignore_header] {
+		return new_error(ErrOptions, "Options 'remove_overflow_cols' and 'ignore_header' can not be used in conjunction", nil)
+	}
+	
+	if r.options[opt_remove_overflow_cols] && r.options[opt_col_integrity] {
+		return new_error(ErrOptions, "Options 'remove_overflow_cols' and 'col_integrity' can not be used in conjunction", nil)
+	}
+	
+	for _, sep := range append([]rune{r.sep_forced}, r.sep_extra...) {
+		if sep != 0 && !valid_separator(sep) {
+			return new_error(ErrSeparator, fmt.Sprintf("Invalid separator: %q", sep), nil)
+		}
+	}
+	
+	if r.charset_forced != "" {
+		charset, ok := charset_names[strings.ToLower(r.charset_forced)]
+		if !ok {
+			return new_error(ErrCharset, "Unknown charset: "+r.charset_forced, nil)
+		}
+		r.charset_forced = charset
+	}
+	return nil
+}
+
+func (r *Reader) parse(mimetype string) (Table, error){
+	r.log_options()
+	
+	if err := r.check_options(); err != nil {
+		return Table{}, err
+	}
+	if err := r.limits.canceled(); err != nil {
+		return Table{}, r.log_fail(err)
+	}
+	
+	mimetype, err := r.format(mimetype)
+	if err != nil {
+		return Table{}, err
+	}
+	
+	var rows Rows
+	if _, ok := workbook_formats[mimetype]; ok {
+		r.limits.start()
+		rows, err = r.read_workbook(mimetype)
+	} else {
+		rows, err = r.read_csv()
+	}
+	if err != nil {
+		return Table{}, err
+	}
+	r.parse_lines(rows)
+	
+	if err := r.empty_rows_error(); err != nil {
+		return Table{}, err
+	}
+	
+	cols		:= r.cols()
+	cols_max	:= slices.Max(cols)
+	
+	if err := r.one_col_error(cols_max); err != nil {
+		return Table{}, err
+	}
+	
+	//	Remove empty columns before check_header()
+	if r.options[opt_remove_empty_cols] {
+		r.remove_empty_cols()
+		
+		cols		= r.cols()
+		cols_max	= slices.Max(cols)
+	}
+	
+	if !r.options[opt_ignore_header] {
+		if r.options[opt_remove_overflow_cols] {
+			if r.check_header(false) == nil {
+				if err := r.empty_rows_error(); err != nil {
+					return Table{}, err
+				}
+				
+				if r.options[opt_remove_empty_cols] {
+					r.remove_empty_cols()
+					
+					cols		= r.cols()
+					cols_max	= slices.Max(cols)
+				}
+				
+				r.remove_overflow_cols()
+				
+				cols		= r.cols()
+				cols_max	= slices.Max(cols)
+			}
+		}
+		
+		if len(r.out_header) == 0 && cols[0] < cols_max {
+			i := slices.IndexFunc(cols, func(n int) bool {
+				return n > cols[0]
+			})
+			return Table{}, r.log_fail(new_error(ErrTooFewHeaders, "", nil).at(r.out[i].Line, cols[0] + 1))
+		}
+	}
+	
+	if r.options[opt_col_integrity] {
+		if cols_max != slices.Min(cols) {
+			i := slices.IndexFunc(cols, func(n int) bool {
+				return n != cols[0]
+			})
+			return Table{}, r.log_fail(new_error(ErrColumnsNotEqual, "", nil).at(r.out[i].Line, 0))
+		}
+	} else {
+		r.fill_empty_cols(cols_max)
+	}
+	
+	if !r.checked_header {
+		//	Optional column header
+		if r.options[opt_optional_header] {
+			if r.check_header(false) == nil {
+				if err := r.empty_rows_error(); err != nil {
+					return Table{}, err
+				}
+				
+				if r.options[opt_remove_empty_cols] {
+					r.remove_empty_cols()
+					
+					cols		= r.cols()
+					cols_max	= slices.Max(cols)
+				}
+			}
+		//	Require column header
+		} else if !r.options[opt_ignore_header] {
+			if err := r.check_header(true); err != nil {
+				return Table{}, err
+			}
+			
+			if err := r.empty_rows_error(); err != nil {
+				return Table{}, err
+			}
+			
+			if r.options[opt_remove_empty_cols] {
+				r.remove_empty_cols()
+				
+				cols		= r.cols()
+				cols_max	= slices.Max(cols)
+			}
+		}
+	}
+	
+	if err := r.one_col_error(cols_max); err != nil {
+		return Table{}, err
+	}
+	
+	if r.non_printable != "" {
+		r.strip_non_printable()
+	}
+	
+	r.log_append(log_info(LOG_ROWS, fmt.Sprintf("Rows found: %d", len(r.out))).count(len(r.out)))
+	return Table{
+		r.out_header,
+		r.out,
+		r.separator,
+		r.rejected,
+	}, nil
+}
+
+func (r *Reader) read_csv() (Rows, error){
+	if err := r.encoding(); err != nil {
+		return nil, r.log_fail(err)
+	}
+	
+	var (
+		rows	Rows
+		src		= r.src_encoded
+		pos		= row_pos{lines: r.src_lines}
+	)
+	for {
+		read := csv.NewReader(bytes.NewReader(src))
+		read.FieldsPerRecord	= -1
+		read.Comma				= r.separator
+		
+		for {
+			line, err := read.Read()
+			if err == io.EOF {
+				return rows, nil
+			}
+			if err == nil {
+				row := pos.row(read, line)
+				if err := r.limits.record(len(rows) + 1, line, row.Line); err != nil {
+					return nil, r.log_fail(err)
+				}
+				if len(rows) % cancel_interval == 0 {
+					if err := r.limits.canceled(); err != nil {
+						return nil, r.log_fail(err)
+					}
+				}
+				rows = append(rows, row)
+				continue
+			}
+			var perr *csv.ParseError
+			if !r.options[opt_lenient] || !errors.As(err, &perr) {
+				if r.non_printable != "" {
+					r.log_non_printable()
+					return nil, new_error(ErrInvalidEncoding, "", nil).at(parse_error(err).Line, 0)
+				}
+				return nil, r.log_fail(parse_error(err))
+			}
+			
+			//	Quarantine the line the record starts on and restart reading on the next line
+			line_num := pos.start_line + perr.StartLine
+			r.reject(line_num, r.src_lines.line(r.src, line_num), perr.Err.Error())
+			
+			pos.start_line	= line_num
+			src				= src[line_offset(src, perr.StartLine):]
+			break
+		}
+	}
+}
+
+func (r *Reader) reject(line int, raw []byte, reason string){
+	r.rejected = append(r.rejected, Rejected{
+		Line:	line,
+		Raw:	slices.Clone(raw),
+		Reason:	reason,
+	})
+	r.log_append(log_warning(LOG_ROW_REJECTED, fmt.Sprintf("Reject unparsable line %d: %s", line, reason)))
+}
+
+//	Byte offset of line n (0-based)
+func line_offset(b []byte, n int) int {
+	i := 0
+	for ; n > 0; n-- {
+		j := bytes.IndexByte(b[i:], '\n')
+		if j == -1 {
+			return len(b)
+		}
+		i += j + 1
+	}
+	return i
+}
+
+//	Position of the record just read (start_line is the line a restarted reader begins after)
+func (p *row_pos) row(read *csv.Reader, record []string) Row {
+	line, _ := read.FieldPos(0)
+	line += p.start_line
+	row := Row{
+		Line:		line,
+		Line_end:	line,
+		Offset:		p.lines.offset(line),
+		Row:		record,
+	}
+	for _, value := range record {
+		row.Line_end += strings.Count(value, "\n")
+	}
+	return row
+}
+
+func (r *Reader) encoding() *Error {
+	src := r.detect_encoding(r.src, false)
+	
+	//	Lines are sanitized one by one so blank lines are kept and row positions match the source
+	lines := strings.Split(r.eol_normalize(r.decode(src)), "\n")
+	r.src_lines = newLine_index(r.charset, int64(len(r.src) - len(src)), r.unbalanced)
+	r.src_lines.scan(src)
+	for i, line := range lines {
+		lines[i] = sanitize_line(line)
+	}
+	return r.src_encoding(strings.Join(lines, "\n"))
+}
+
+//	Detect encoding and strip BOM (partial source may end with an incomplete UTF8 char)
+func (r *Reader) detect_encoding(src []byte, partial bool) []byte {
+	if r.charset_forced != "" {
+		r.charset = r.charset_forced
+		r.log_append(log_info(LOG_CHARSET, "Charset (forced): "+r.charset))
+		return strip_bom(src, r.charset)
+	}
+	
+	//	Detect and strip UTF8 BOM
+	if bytes.HasPrefix(src, []byte(BOM_UTF8)) {
+		r.charset = charset_utf8
+		r.log_append(log_info(LOG_CHARSET, "UTF8 BOM found"))
+		return src[len(BOM_UTF8):]
+	}
+	
+	//	Detect and strip UTF16 BOM
+	if bytes.HasPrefix(src, []byte(BOM_UTF16LE)) {
+		r.charset = charset_utf16le
+		r.log_append(log_info(LOG_CHARSET, "UTF16LE BOM found"))
+		return src[len(BOM_UTF16LE):]
+	}
+	if bytes.HasPrefix(src, []byte(BOM_UTF16BE)) {
+		r.charset = charset_utf16be
+		r.log_append(log_info(LOG_CHARSET, "UTF16BE BOM found"))
+		return src[len(BOM_UTF16BE):]
+	}
+	
+	//	UTF16 without BOM (must be checked before UTF8 as NUL bytes are valid UTF8)
+	if charset := detect_utf16(src); charset != "" {
+		r.charset = charset
+		r.log_append(log_info(LOG_CHARSET, charset+" detected"))
+		return src
+	}
+	
+	valid := src
+	if partial {
+		valid = trim_partial_rune(src)
+	}
+	
+	//	Valid UTF8
+	if utf8.Valid(valid) {
+		r.charset = charset_utf8
+		r.log_append(log_info(LOG_CHARSET, "UTF8 validated"))
+		return src
+	}
+	
+	r.charset = detect_charset(src[:min(len(src), sniff_size)])
+	r.log_append(log_info(LOG_CHARSET, "Charset detected: "+r.charset))
+	return src
+}
+
+//	Normalize line endings (lone CR from old Mac exports becomes a line break) and log the detected style
+func (r *Reader) eol_normalize(s string) string {
+	e := &eol{}
+	out := e.string(s)
+	//	A stray quote would keep every later CR in one quoted cell
+	if e.quoted && strings.Contains(out, "\r") {
+		r.unbalanced = true
+		r.log_append(log_warning(LOG_LINE_ENDING, "Unbalanced quotes: every CR is a line break"))
+		e	= &eol{unbalanced: true}
+		out	= e.string(s)
+	}
+	s = out
+	r.line_ending = e.style()
+	if r.line_ending != "" {
+		r.log_append(log_info(LOG_LINE_ENDING, "Line ending: "+r.line_ending))
+	}
+	return s
+}
+
+//	Decode source bytes to UTF8
+func (r *Reader) decode(b []byte) string {
+	switch r.charset {
+	case charset_utf16le, charset_utf16be:
+		s, _ := decode_utf16(b, r.charset == charset_utf16be)
+		return string(s)
+	case charset_utf8:
+		return string(b)
+	}
+	return decode_charset(b, r.charset)
+}
+
+func (r *Reader) strip_non_printable(){
+	c := strip_non_printable_line(r.out_header)
+	for i := range r.out {
+		c += strip_non_printable_line(r.out[i].Row)
+	}
+	r.log_append(log_warning(LOG_VALUES_REPLACED, fmt.Sprintf("Values replaced (non-printable): %d", c)).count(c))
+}
+
+func (r *Reader) parse_lines(rows Rows){
+	for _, row := range rows {
+		//	Remove empty rows
+		if trim_line(row.Row) {
+			r.out = append(r.out, row)
+		}
+	}
+}
+
+func (r *Reader) remove_empty_cols(){
+	cols_max	:= slices.Max(r.cols())
+	cols		:= make([]bool, cols_max)
+	for _, row := range r.out {
+		for i, value := range row.Row {
+			if value != "" {
+				cols[i] = true
+			}
+		}
+	}
+	
+	for c := cols_max - 1; c >= 0; c-- {
+		if cols[c] {
+			continue
+		}
+		
+		r.log_append(log_warning(LOG_COLUMN_REMOVED, fmt.Sprintf("Remove empty column: %d", c + 1)).column(c + 1))
+		if len(r.out_header) > c {
+			r.out_header = append(r.out_header[:c], r.out_header[c+1:]...)
+		}
+		for i := range r.out {
+			if len(r.out[i].Row) > c {
+				r.out[i].Row = append(r.out[i].Row[:c], r.out[i].Row[c+1:]...)
+			}
+		}
+	}
+}
+
+func (r *Reader) remove_overflow_cols(){
+	cols_max := len(r.out_header)
+	for i, row := range r.out {
+		if len(row.Row) > cols_max {
+			r.log_append(log_warning(LOG_OVERFLOW_REMOVED, fmt.Sprintf("Remove overflow columns line: %d", row.Line)).line(row.Line))
+			r.out[i].Row = r.out[i].Row[:cols_max]
+		}
+	}
+}
+
+func (r *Reader) check_header(error_log bool) error {
+	r.checked_header = true
+	
+	first_row := r.out[0].Row
+	if err := header_error(first_row); err != nil {
+		err.Line = r.out[0].Line
+		if error_log {
+			r.log_fail(err)
+		}
+		return err
+	}
+	
+	r.log_append(log_info(LOG_HEADER, "Column headers found"))
+	r.out_header	= first_row
+	r.out			= r.out[1:]
+	return nil
+}
+
+func header_error(first_row []string) *Error {
+	numeric := -1
+	for c, value := range first_row {
+		if value == "" {
+			return new_error(ErrHeaderEmpty, "", nil).at(0, c + 1)
+		}
+		
+		value = re_col_heading.ReplaceAllString(value, "")
+		if _, err := strconv.Atoi(value); err == nil && numeric == -1 {
+			numeric = c
+		}
+	}
+	
+	if numeric != -1 {
+		return new_error(ErrHeaderRequired, "", nil).at(0, numeric + 1)
+	}
+	return nil
+}
+
+func (r *Reader) fill_empty_cols(cols_max int){
+	for t, row := range r.out {
+		l := len(row.Row)
+		if l != cols_max {
+			r.log_append(log_warning(LOG_ROW_FILLED, fmt.Sprintf("Fill empty columns line: %d", row.Line)).line(row.Line))
+			for i := 0; i < cols_max - l; i++ {
+				r.out[t].Row = append(r.out[t].Row, "")
+			}
+		}
+	}
+}
+
+func (r *Reader) get_separator(s string) *Error {
+	if r.sep_forced != 0 {
+		r.separator = r.sep_forced
+		r.log_append(log_info(LOG_SEPARATOR, "Separator (forced): "+string(r.separator)))
+		return nil
+	}
+	
+	seps := r.separators()
+	if sep, confidence, ok := sniff_separator(s, seps); ok {
+		r.separator			= sep
+		r.sep_confidence	= confidence
+		r.log_append(log_info(LOG_SEPARATOR, fmt.Sprintf("Separator (sniffed): %s confidence: %.2f", string(r.separator), confidence)))
+		return nil
+	}
+	
+	c := newCount_sep()
+	for _, sep := range seps {
+		c.count_sep(sep, strings.Count(s, string(sep)))
+	}
+	
+	sep, err := c.get_sep()
+	if err != nil {
+		return new_error(ErrSeparator, err.Error(), err)
+	}
+	
+	r.separator = sep
+	r.log_append(log_info(LOG_SEPARATOR, "Separator: "+string(r.separator)))
+	return nil
+}
+
+//	Separator candidates
+func (r *Reader) separators() []rune {
+	seps := slices.Clone(separators)
+	for _, sep := range r.sep_extra {
+		if !slices.Contains(seps, sep) {
+			seps = append(seps, sep)
+		}
+	}
+	return seps
+}
+
+func (r *Reader) src_encoding(s string) *Error {
+	r.src_encoded	= []byte(s)
+	r.non_printable = sanitize.Non_printable(s)
+	
+	if s == "" {
+		return new_error(ErrEmpty, "", nil)
+	}
+	
+	return r.get_separator(s)
+}
+
+func (r *Reader) cols() []int {
+	cols := make([]int, len(r.out))
+	for i, row := range r.out {
+		cols[i] = len(row.Row)
+	}
+	return cols
+}
+
+func (r *Reader) log_non_printable(){
+	len_total			:= len(r.src_encoded)
+	len_non_printable	:= len(r.non_printable)
+	percent				:= float32(len_non_printable) / float32(len_total) * 100
+	r.log_append(log_warning(LOG_NON_PRINTABLE, fmt.Sprintf("Non-printable chars found (%d / %d = %.2f%%): %s", len_non_printable, len_total, percent, r.non_printable)).count(len_non_printable))
+}
+
+func (r *Reader) log_options(){
+	var opts []string
+	for k, v := range r.options {
+		if v {
+			opts = append(opts, k)
+		}
+	}
+	if len(opts) != 0 {
+		r.log_append(log_info(LOG_OPTIONS, "Options: "+strings.Join(opts, ", ")))
+	}
+}
+
+func (r *Reader) log_append(e Log_entry){
+	r.log = append(r.log, e)
+}
+
+//	Log error and return it
+func (r *Reader) log_fail(err *Error) error {
+	r.log_append(log_error(err.code, err.Error()))
+	return err
+}
+
+//	Parse error with the position from encoding/csv
+func parse_error(err error) *Error {
+	e := new_error(ErrParse, "Unable to parse CSV: "+err.Error(), err)
+	var perr *csv.ParseError
+	if errors.As(err, &perr) {
+		e.at(perr.Line, perr.Column)
+	}
+	return e
+}
+
+func (r *Reader) empty_rows_error() error {
+	if len(r.out) == 0 {
+		return r.log_fail(new_error(ErrEmpty, "", nil))
+	}
+	return nil
+}
+
+func (r *Reader) one_col_error(cols_max int) error {
+	if cols_max == 1 {
+		return r.log_fail(new_error(ErrOneColumn, "", nil))
+	}
+	return nil
+}
+
+func trim_partial_rune(b []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(b); i++ {
+		if !utf8.RuneStart(b[len(b)-i]) {
+			continue
+		}
+		if !utf8.FullRune(b[len(b)-i:]) {
+			return b[:len(b)-i]
+		}
+		break
+	}
+	return b
+}
+
+//	Separators accepted by encoding/csv
+func valid_separator(sep rune) bool {
+	return sep != '"' && sep != '\r' && sep != '\n' && sep != utf8.RuneError && utf8.ValidRune(sep)
 }
\ No newline at end of file
@@ -1,253 +1,751 @@
-package csv
-
-import (
-	"fmt"
-	"strings"
-	"testing"
-)
-
-type (
-	tester interface {
-		verify(*testing.T)
-	}
-	
-	test_error struct {
-		reader		func(t *testing.T) *Reader
-		input		string
-		error		string
-	}
-	
-	test_output struct {
-		reader		func(t *testing.T) *Reader
-		input		string
-		header		string
-		rows		string
-	}
-)
-
-func Test_error(t *testing.T){
-	t.Run("empty", func(t *testing.T){
-		tests := []test_error{{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("")
-			},
-			input:	"",
-			error:	"CSV empty",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("")
-			},
-			input:	"head1\ntest1",
-			error:	"CSV must have more than one column",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("")
-			},
-			input:	"head1,head2",
-			error:	"CSV empty",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Remove_overflow_cols()
-			},
-			input:	"head1,head2",
-			error:	"CSV empty",
-		}}
-		verify_test(t, tests)
-	})
-	
-	t.Run("too few column headers", func(t *testing.T){
-		tests := []test_error{{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("")
-			},
-			input:	"head1\ntest1,test2",
-			error:	"CSV has too few column headers",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Remove_overflow_cols()
-			},
-			input:	"100\ntest1,test2",
-			error:	"CSV has too few column headers",
-		}}
-		verify_test(t, tests)
-	})
-	
-	t.Run("columns not equal", func(t *testing.T){
-		tests := []test_error{{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Col_integrity()
-			},
-			input:	"head1,head2\ntest1",
-			error:	"Columns in CSV not equal",
-		}}
-		verify_test(t, tests)
-	})
-	
-	t.Run("column headers", func(t *testing.T){
-		tests := []test_error{{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("")
-			},
-			input:	"head1,,head3\ntest1,test2,test3\ntest1,test2,test3",
-			error:	"Column headers cannot be empty",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("")
-			},
-			input:	"head1,100,head3\ntest1,test2,test3\ntest1,test2,test3",
-			error:	"Column headers in CSV required",
-		}}
-		verify_test(t, tests)
-	})
-}
-
-func Test_ouput(t *testing.T){
-	t.Run("fill empty columns", func(t *testing.T){
-		tests := []test_output{{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Ignore_header()
-			},
-			input:	"test1,test2,test3\ntest1\ntest1,test2",
-			rows:	"test1,test2,test3\ntest1,,\ntest1,test2,",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Ignore_header()
-			},
-			input:	"test1,test2\ntest1\ntest1,test2,test3",
-			rows:	"test1,test2,\ntest1,,\ntest1,test2,test3",
-		}}
-		verify_test(t, tests)
-	})
-	
-	t.Run("remove empty columns", func(t *testing.T){
-		tests := []test_output{{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Remove_empty_cols()
-			},
-			input:	"head1,head2,head3\ntest1,,test3\ntest1,,test3",
-			header:	"head1,head3",
-			rows:	"test1,test3\ntest1,test3",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Remove_empty_cols()
-			},
-			input:	"head1,,head3\ntest1,,test3\ntest1,,test3",
-			header:	"head1,head3",
-			rows:	"test1,test3\ntest1,test3",
-		}}
-		verify_test(t, tests)
-	})
-	
-	t.Run("header and rows", func(t *testing.T){
-		tests := []test_output{{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("")
-			},
-			input:	"head1,head2,head3\ntest1,test2,test3\ntest1,test2,test3",
-			header:	"head1,head2,head3",
-			rows:	"test1,test2,test3\ntest1,test2,test3",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Optional_header()
-			},
-			input:	"head1,head2,head3\ntest1,test2,test3\ntest1,test2,test3",
-			header:	"head1,head2,head3",
-			rows:	"test1,test2,test3\ntest1,test2,test3",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Optional_header()
-			},
-			input:	"test1,,test3\ntest1,test2,test3",
-			rows:	"test1,,test3\ntest1,test2,test3",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Ignore_header()
-			},
-			input:	"head1,head2,head3\ntest1,test2,test3\ntest1,test2,test3",
-			rows:	"head1,head2,head3\ntest1,test2,test3\ntest1,test2,test3",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Remove_overflow_cols()
-			},
-			input:	"head1,head2,head3\ntest1,test2,test3,test4\ntest1,test2",
-			header:	"head1,head2,head3",
-			rows:	"test1,test2,test3\ntest1,test2,",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Remove_empty_cols().
-					Remove_overflow_cols()
-			},
-			input:	"head1,head2,,head4\ntest1,test2,,test4,test5\ntest1,",
-			header:	"head1,head2,head4",
-			rows:	"test1,test2,test4\ntest1,,",
-		},{
-			reader:	func(t *testing.T) *Reader {
-				return NewReader("").
-					Remove_empty_cols().
-					Remove_overflow_cols()
-			},
-			input:	"head1,head2,head3,head4\ntest1,test2,,test4,test5\ntest1,",
-			header:	"head1,head2,head4",
-			rows:	"test1,test2,test4\ntest1,,",
-		}}
-		verify_test(t, tests)
-	})
-}
-
-func (e test_error) verify(t *testing.T){
-	r := e.reader(t)
-	_, err := r.Bytes([]byte(e.input), "")
-	if err == nil {
-		t.Fatal("Expected an error")
-	}
-	
-	if err.Error() != e.error {
-		t.Fatalf("Expected error '%s', got '%v'", e.error, err)
-	}
-	
-	fmt.Println(strings.Join(r.Log(), "\n"))
-}
-
-func (o test_output) verify(t *testing.T){
-	r := o.reader(t)
-	out, err := r.Bytes([]byte(o.input), "")
-	if err != nil {
-		t.Fatalf("Unexpected error: %s", err)
-	}
-	
-	header := strings.Join(out.Header, ",")
-	if header != o.header {
-		t.Fatalf("Want: %s\n\nGot: %s", o.header, header)
-	}
-	
-	s := make([]string, len(out.Rows))
-	for i, line := range out.Rows {
-		s[i] = strings.Join(line.Row, ",")
-	}
-	
-	rows := strings.Join(s, "\n")
-	if rows != o.rows {
-		t.Fatalf("Want: %s\n\nGot: %s", o.rows, rows)
-	}
-	
-	fmt.Println(strings.Join(r.Log(), "\n"))
-}
-
-func verify_test[T tester](t *testing.T, tests []T){
-	for i, tt := range tests {
-		fmt.Println("test:", i, "\n---")
-		tt.verify(t)
-	}
+package csv
+
+import (
+	"fmt"
+	"errors"
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"path/filepath"
+)
+
+type (
+	tester interface {
+		verify(*testing.T)
+	}
+	
+	test_error struct {
+		reader		func(t *testing.T) *Reader
+		input		string
+		error		string
+		is			error
+		line		int
+		column		int
+	}
+	
+	test_output struct {
+		reader		func(t *testing.T) *Reader
+		input		string
+		header		string
+		rows		string
+	}
+)
+
+func Test_error(t *testing.T){
+	t.Run("empty", func(t *testing.T){
+		tests := []test_error{{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("")
+			},
+			input:	"",
+			error:	"CSV empty",
+			is:		ErrEmpty,
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("")
+			},
+			input:	"head1\ntest1",
+			error:	"CSV must have more than one column",
+			is:		ErrOneColumn,
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("")
+			},
+			input:	"head1,head2",
+			error:	"CSV empty",
+			is:		ErrEmpty,
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Remove_overflow_cols()
+			},
+			input:	"head1,head2",
+			error:	"CSV empty",
+			is:		ErrEmpty,
+		}}
+		verify_test(t, tests)
+	})
+	
+	t.Run("too few column headers", func(t *testing.T){
+		tests := []test_error{{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("")
+			},
+			input:	"head1\ntest1,test2",
+			error:	"CSV has too few column headers",
+			is:		ErrTooFewHeaders,
+			line:	2,
+			column:	2,
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Remove_overflow_cols()
+			},
+			input:	"100\ntest1,test2",
+			error:	"CSV has too few column headers",
+			is:		ErrTooFewHeaders,
+			line:	2,
+			column:	2,
+		}}
+		verify_test(t, tests)
+	})
+	
+	t.Run("columns not equal", func(t *testing.T){
+		tests := []test_error{{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Col_integrity()
+			},
+			input:	"head1,head2\ntest1",
+			error:	"Columns in CSV not equal",
+			is:		ErrColumnsNotEqual,
+			line:	2,
+		}}
+		verify_test(t, tests)
+	})
+	
+	t.Run("column headers", func(t *testing.T){
+		tests := []test_error{{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("")
+			},
+			input:	"head1,,head3\ntest1,test2,test3\ntest1,test2,test3",
+			error:	"Column headers cannot be empty",
+			is:		ErrHeaderEmpty,
+			line:	1,
+			column:	2,
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("")
+			},
+			input:	"head1,100,head3\ntest1,test2,test3\ntest1,test2,test3",
+			error:	"Column headers in CSV required",
+			is:		ErrHeaderRequired,
+			line:	1,
+			column:	2,
+		}}
+		verify_test(t, tests)
+	})
+	
+	t.Run("parse", func(t *testing.T){
+		tests := []test_error{{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("")
+			},
+			input:	"head1,head2\ntest1,te\"st2",
+			error:	`Unable to parse CSV: parse error on line 2, column 9: bare " in non-quoted-field`,
+			is:		ErrParse,
+			line:	2,
+			column:	9,
+		}}
+		verify_test(t, tests)
+	})
+	
+	t.Run("cause", func(t *testing.T){
+		_, err := NewReader("").File(filepath.Join(t.TempDir(), "missing.csv"), "")
+		if !errors.Is(err, ErrRead) || !strings.Contains(err.Error(), "missing.csv") {
+			t.Fatalf("Want cause in error: %v", err)
+		}
+	})
+}
+
+func Test_ouput(t *testing.T){
+	t.Run("fill empty columns", func(t *testing.T){
+		tests := []test_output{{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Ignore_header()
+			},
+			input:	"test1,test2,test3\ntest1\ntest1,test2",
+			rows:	"test1,test2,test3\ntest1,,\ntest1,test2,",
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Ignore_header()
+			},
+			input:	"test1,test2\ntest1\ntest1,test2,test3",
+			rows:	"test1,test2,\ntest1,,\ntest1,test2,test3",
+		}}
+		verify_test(t, tests)
+	})
+	
+	t.Run("remove empty columns", func(t *testing.T){
+		tests := []test_output{{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Remove_empty_cols()
+			},
+			input:	"head1,head2,head3\ntest1,,test3\ntest1,,test3",
+			header:	"head1,head3",
+			rows:	"test1,test3\ntest1,test3",
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Remove_empty_cols()
+			},
+			input:	"head1,,head3\ntest1,,test3\ntest1,,test3",
+			header:	"head1,head3",
+			rows:	"test1,test3\ntest1,test3",
+		}}
+		verify_test(t, tests)
+	})
+	
+	t.Run("header and rows", func(t *testing.T){
+		tests := []test_output{{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("")
+			},
+			input:	"head1,head2,head3\ntest1,test2,test3\ntest1,test2,test3",
+			header:	"head1,head2,head3",
+			rows:	"test1,test2,test3\ntest1,test2,test3",
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Optional_header()
+			},
+			input:	"head1,head2,head3\ntest1,test2,test3\ntest1,test2,test3",
+			header:	"head1,head2,head3",
+			rows:	"test1,test2,test3\ntest1,test2,test3",
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Optional_header()
+			},
+			input:	"test1,,test3\ntest1,test2,test3",
+			rows:	"test1,,test3\ntest1,test2,test3",
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Ignore_header()
+			},
+			input:	"head1,head2,head3\ntest1,test2,test3\ntest1,test2,test3",
+			rows:	"head1,head2,head3\ntest1,test2,test3\ntest1,test2,test3",
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Remove_overflow_cols()
+			},
+			input:	"head1,head2,head3\ntest1,test2,test3,test4\ntest1,test2",
+			header:	"head1,head2,head3",
+			rows:	"test1,test2,test3\ntest1,test2,",
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Remove_empty_cols().
+					Remove_overflow_cols()
+			},
+			input:	"head1,head2,,head4\ntest1,test2,,test4,test5\ntest1,",
+			header:	"head1,head2,head4",
+			rows:	"test1,test2,test4\ntest1,,",
+		},{
+			reader:	func(t *testing.T) *Reader {
+				return NewReader("").
+					Remove_empty_cols().
+					Remove_overflow_cols()
+			},
+			input:	"head1,head2,head3,head4\ntest1,test2,,test4,test5\ntest1,",
+			header:	"head1,head2,head4",
+			rows:	"test1,test2,test4\ntest1,,",
+		}}
+		verify_test(t, tests)
+	})
+}
+
+func Test_rows(t *testing.T){
+	t.Run("stream", func(t *testing.T){
+		input := "head1;head2;head3\ntest1;test2;test3\n\ntest1;test2"
+		
+		var rows []string
+		r := NewReader("")
+		for row, err := range r.Rows(strings.NewReader(input)) {
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			rows = append(rows, strings.Join(row.Row, ";"))
+		}
+		
+		header := strings.Join(r.Header(), ";")
+		if header != "head1;head2;head3" {
+			t.Fatalf("Want: %s\n\nGot: %s", "head1;head2;head3", header)
+		}
+		
+		want := "test1;test2;test3\ntest1;test2;"
+		if got := strings.Join(rows, "\n"); got != want {
+			t.Fatalf("Want: %s\n\nGot: %s", want, got)
+		}
+		
+		fmt.Println(strings.Join(r.Log(), "\n"))
+	})
+	
+	t.Run("stream beyond sniffing window", func(t *testing.T){
+		var b strings.Builder
+		b.WriteString("name,amount\n")
+		for b.Len() < sniff_size * 3 {
+			b.WriteString("n\xe6vn,1\n")
+		}
+		//	Non-printable chars after the window are stripped as well
+		b.WriteString("x\ay,1\n")
+		
+		r := NewReader("")
+		out, err := NewReader("").Bytes([]byte(b.String()), "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		
+		i := 0
+		for row, err := range r.Rows(strings.NewReader(b.String())) {
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if got, want := strings.Join(row.Row, ","), strings.Join(out.Rows[i].Row, ","); got != want {
+				t.Fatalf("Row %d want: %s\n\nGot: %s", i, want, got)
+			}
+			i++
+		}
+		
+		if i != len(out.Rows) {
+			t.Fatalf("Want %d rows, got %d", len(out.Rows), i)
+		}
+	})
+	
+	t.Run("stream errors", func(t *testing.T){
+		tests := map[string]string{
+			"":							"CSV empty",
+			"head1,head2":				"CSV empty",
+			"head1,100\ntest1,test2":	"Column headers in CSV required",
+			"head1\ntest1,test2":		"CSV must have more than one column",
+		}
+		for input, want := range tests {
+			var got error
+			for _, err := range NewReader("").Rows(strings.NewReader(input)) {
+				if err != nil {
+					got = err
+				}
+			}
+			if got == nil || got.Error() != want {
+				t.Fatalf("Expected error '%s', got '%v'", want, got)
+			}
+		}
+		
+		//	Without column header the first row determines the columns as in Bytes()
+		input := "1,2\n3,4,5\n6,7"
+		_, want := NewReader("").Optional_header().Bytes([]byte(input), "")
+		var got error
+		for _, err := range NewReader("").Optional_header().Rows(strings.NewReader(input)) {
+			if err != nil {
+				got = err
+			}
+		}
+		if !errors.Is(want, ErrTooFewHeaders) || !errors.Is(got, ErrTooFewHeaders) {
+			t.Fatalf("Expected too few headers, got '%v' and '%v'", want, got)
+		}
+	})
+}
+
+func Test_utf16(t *testing.T){
+	encode := func(s string, big_endian bool, bom string) []byte {
+		b := []byte(bom)
+		for _, c := range utf16.Encode([]rune(s)) {
+			if big_endian {
+				b = append(b, byte(c >> 8), byte(c))
+			} else {
+				b = append(b, byte(c), byte(c >> 8))
+			}
+		}
+		return b
+	}
+	
+	input := "Navn\tBeløb\r\nÆble\t12,50\r\nPære\t8\r\n"
+	tests := [][]byte{
+		encode(input, false, BOM_UTF16LE),
+		encode(input, true, BOM_UTF16BE),
+		encode(input, false, ""),
+		encode(input, true, ""),
+	}
+	for _, b := range tests {
+		r := NewReader("")
+		out, err := r.Bytes(b, "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		verify_table(t, out, "Navn,Beløb", "Æble,12,50\nPære,8")
+		fmt.Println(strings.Join(r.Log(), "\n"))
+	}
+	
+	t.Run("stream", func(t *testing.T){
+		var s strings.Builder
+		s.WriteString("Navn\tBeløb\r\n")
+		for s.Len() < sniff_size {
+			s.WriteString("Æble 🍏\t12,50\r\n")
+		}
+		
+		count := 0
+		for row, err := range NewReader("").Rows(bytes.NewReader(encode(s.String(), false, BOM_UTF16LE))) {
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if got := strings.Join(row.Row, ","); got != "Æble,12,50" {
+				t.Fatalf("Want: Æble,12,50\n\nGot: %s", got)
+			}
+			count++
+		}
+		if count == 0 {
+			t.Fatal("Expected rows")
+		}
+	})
+}
+
+func Test_charset(t *testing.T){
+	encode := func(s, charset string) []byte {
+		var b []byte
+		for _, c := range s {
+			if c < 0x80 {
+				b = append(b, byte(c))
+				continue
+			}
+			i := slices.Index(charset_tables[charset][:], c)
+			if i == -1 {
+				t.Fatalf("Char '%c' not in %s", c, charset)
+			}
+			b = append(b, byte(i + 0x80))
+		}
+		return b
+	}
+	
+	tests := []struct{
+		input	string
+		charset	string
+		rows	string
+	}{{
+		input:		"Navn;Beløb\n“Åbenrå” – Søndergade;€ 1.234,50\nDon’t;½",
+		charset:	charset_windows1252,
+		rows:		"“Åbenrå” - Søndergade,€ 1.234,50\nDon’t,½",
+	},{
+		input:		"Navn;Beløb\nSøren Æbelø;100 €\nKøbenhavn;50 €",
+		charset:	charset_iso8859_15,
+		rows:		"Søren Æbelø,100 €\nKøbenhavn,50 €",
+	},{
+		input:		"Navn;Beløb\nSøren Æbelø;100\nKøbenhavn Å;50",
+		charset:	charset_cp865,
+		rows:		"Søren Æbelø,100\nKøbenhavn Å,50",
+	},{
+		input:		"Navn;Beløb\nSøren Æbelø;100\nKøbenhavn Å;50",
+		charset:	charset_mac_roman,
+		rows:		"Søren Æbelø,100\nKøbenhavn Å,50",
+	}}
+	for _, tt := range tests {
+		r := NewReader("")
+		out, err := r.Bytes(encode(tt.input, tt.charset), "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if r.charset != tt.charset {
+			t.Fatalf("Want charset %s, got %s", tt.charset, r.charset)
+		}
+		verify_table(t, out, "Navn,Beløb", tt.rows)
+		fmt.Println(strings.Join(r.Log(), "\n"))
+	}
+	
+	t.Run("forced", func(t *testing.T){
+		r := NewReader("").Charset("cp865")
+		out, err := r.Bytes(encode("Navn;Beløb\nÅ;1", charset_cp865), "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		verify_table(t, out, "Navn,Beløb", "Å,1")
+		
+		if _, err := NewReader("").Charset("ebcdic").Bytes([]byte("a,b\n1,2"), ""); err == nil || err.Error() != "Unknown charset: ebcdic" {
+			t.Fatalf("Expected error 'Unknown charset: ebcdic', got '%v'", err)
+		}
+	})
+}
+
+func Test_separator(t *testing.T){
+	tests := []struct{
+		reader		func() *Reader
+		input		string
+		separator	rune
+		rows		string
+	}{{
+		reader:		func() *Reader {
+			return NewReader("").Separator_candidates('|')
+		},
+		input:		"head1|head2|head3\ntest1|test2|test3",
+		separator:	'|',
+		rows:		"test1,test2,test3",
+	},{
+		reader:		func() *Reader {
+			return NewReader("").Separator(':')
+		},
+		input:		"head1:head2;x\ntest1:test2,y",
+		separator:	':',
+		rows:		"test1,test2,y",
+	},{
+		reader:		func() *Reader {
+			return NewReader("")
+		},
+		input:		"head1;head2\ntest1;test2",
+		separator:	';',
+		rows:		"test1,test2",
+	}}
+	for _, tt := range tests {
+		r := tt.reader()
+		out, err := r.Bytes([]byte(tt.input), "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if out.Separator != tt.separator {
+			t.Fatalf("Want separator %q, got %q", tt.separator, out.Separator)
+		}
+		
+		s := make([]string, len(out.Rows))
+		for i, line := range out.Rows {
+			s[i] = strings.Join(line.Row, ",")
+		}
+		if got := strings.Join(s, "\n"); got != tt.rows {
+			t.Fatalf("Want: %s\n\nGot: %s", tt.rows, got)
+		}
+		fmt.Println(strings.Join(r.Log(), "\n"))
+	}
+	
+	if _, err := NewReader("").Separator('"').Bytes([]byte("a,b"), ""); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
+
+func Test_lenient(t *testing.T){
+	input := "head1,head2\ntest1,\"multi\nline\"\ntest2,te\"st\ntest3,test4\n\"test5,test6\ntest7,test8"
+	if _, err := NewReader("").Bytes([]byte(input), ""); !errors.Is(err, ErrParse) {
+		t.Fatalf("Expected parse error, got %v", err)
+	}
+	
+	r := NewReader("").Lenient()
+	out, err := r.Bytes([]byte(input), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	s := make([]string, len(out.Rows))
+	for i, line := range out.Rows {
+		s[i] = strings.Join(line.Row, ",")
+	}
+	if got, want := strings.Join(s, "|"), "test1,multi\nline|test3,test4|test7,test8"; got != want {
+		t.Fatalf("Want: %s\n\nGot: %s", want, got)
+	}
+	
+	want := []Rejected{
+		{4, []byte(`test2,te"st`), `bare " in non-quoted-field`},
+		{6, []byte(`"test5,test6`), `extraneous or missing " in quoted-field`},
+	}
+	if !slices.EqualFunc(out.Rejected, want, func(a, b Rejected) bool {
+		return a.Line == b.Line && bytes.Equal(a.Raw, b.Raw) && a.Reason == b.Reason
+	}){
+		t.Fatalf("Unexpected rejected: %+v", out.Rejected)
+	}
+	fmt.Println(strings.Join(r.Log(), "\n"))
+	
+	//	Raw bytes are not decoded or normalized
+	out, err = NewReader("").Lenient().Bytes([]byte("head1,head2\r\n  t\xe6st, te\"st \r\ntest3,test4"), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(out.Rejected) != 1 || out.Rejected[0].Line != 2 || string(out.Rejected[0].Raw) != "  t\xe6st, te\"st " {
+		t.Fatalf("Unexpected rejected: %+v", out.Rejected)
+	}
+	
+	//	Stray quote in a CR-only file does not join the following lines
+	for _, input := range []string{"name,note\rdesk,say \"hi\rchair,ok\rtable,fine\r", "name,note\ndesk,say \"hi\nchair,ok\ntable,fine\n"} {
+		out, err = NewReader("").Lenient().Bytes([]byte(input), "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(out.Rows) != 2 || len(out.Rejected) != 1 || out.Rejected[0].Line != 2 || string(out.Rejected[0].Raw) != `desk,say "hi` {
+			t.Fatalf("Unexpected rows %+v rejected: %+v", out.Rows, out.Rejected)
+		}
+		
+		var got error
+		for _, err := range NewReader("").Rows(strings.NewReader(input)) {
+			if err != nil {
+				got = err
+			}
+		}
+		var cerr *Error
+		if !errors.Is(got, ErrParse) || !errors.As(got, &cerr) || cerr.Line != 2 {
+			t.Fatalf("Expected parse error on line 2, got %v", got)
+		}
+	}
+}
+
+func Test_row_position(t *testing.T){
+	input := "head1,head2\r\n\r\ntest1,\"multi\r\nline\"\r\n\r\ntest2,test3"
+	want := []Row{
+		{Line: 3, Line_end: 4, Offset: 15},
+		{Line: 6, Line_end: 6, Offset: 38},
+	}
+	verify := func(rows Rows){
+		if len(rows) != len(want) {
+			t.Fatalf("Want %d rows, got %d", len(want), len(rows))
+		}
+		for i, row := range rows {
+			if row.Line != want[i].Line || row.Line_end != want[i].Line_end || row.Offset != want[i].Offset {
+				t.Fatalf("Row %d want %d-%d at %d, got %d-%d at %d", i, want[i].Line, want[i].Line_end, want[i].Offset, row.Line, row.Line_end, row.Offset)
+			}
+		}
+	}
+	
+	out, err := NewReader("").Bytes([]byte(input), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	verify(out.Rows)
+	
+	var rows Rows
+	for row, err := range NewReader("").Rows(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		rows = append(rows, row)
+	}
+	verify(rows)
+	
+	//	Offsets are in the source bytes (UTF16 with BOM)
+	utf16_src := []byte(BOM_UTF16LE)
+	for _, c := range utf16.Encode([]rune(input)) {
+		utf16_src = append(utf16_src, byte(c), byte(c >> 8))
+	}
+	for i := range want {
+		want[i].Offset = 2 + want[i].Offset * 2
+	}
+	out, err = NewReader("").Bytes(utf16_src, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	verify(out.Rows)
+	
+	rows = nil
+	for row, err := range NewReader("").Rows(bytes.NewReader(utf16_src)) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		rows = append(rows, row)
+	}
+	verify(rows)
+	
+	_, err = NewReader("").Col_integrity().Bytes([]byte(input+"\ntest4"), "")
+	var cerr *Error
+	if !errors.As(err, &cerr) || cerr.Line != 7 {
+		t.Fatalf("Expected error on line 7, got %v", err)
+	}
+}
+
+func Test_line_ending(t *testing.T){
+	tests := []struct{
+		input		string
+		line_ending	string
+		rows		string
+	}{{
+		input:			"head1;head2\rtest1;test2\rtest3;test4\r",
+		line_ending:	LINE_ENDING_CR,
+		rows:			"test1,test2\ntest3,test4",
+	},{
+		input:			"head1;head2\r\ntest1;\"multi\rline\"\r\ntest3;test4",
+		line_ending:	LINE_ENDING_CRLF,
+		rows:			"test1,multi\rline\ntest3,test4",
+	},{
+		input:			"head1;head2\ntest1;test2\rtest3;test4",
+		line_ending:	LINE_ENDING_MIXED,
+		rows:			"test1,test2\ntest3,test4",
+	}}
+	for _, tt := range tests {
+		r := NewReader("")
+		out, err := r.Bytes([]byte(tt.input), "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if r.line_ending != tt.line_ending {
+			t.Fatalf("Want line ending %s, got %s", tt.line_ending, r.line_ending)
+		}
+		
+		s := make([]string, len(out.Rows))
+		for i, line := range out.Rows {
+			s[i] = strings.Join(line.Row, ",")
+		}
+		if got := strings.Join(s, "\n"); got != tt.rows {
+			t.Fatalf("Want: %s\n\nGot: %s", tt.rows, got)
+		}
+		
+		var rows []string
+		for row, err := range NewReader("").Rows(strings.NewReader(tt.input)) {
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			rows = append(rows, strings.Join(row.Row, ","))
+		}
+		if got := strings.Join(rows, "\n"); got != tt.rows {
+			t.Fatalf("Stream want: %s\n\nGot: %s", tt.rows, got)
+		}
+		fmt.Println(strings.Join(r.Log(), "\n"))
+	}
+	
+	d, err := Sniff([]byte("a;b\r1;2\r"))
+	if err != nil || d.Line_ending != LINE_ENDING_CR {
+		t.Fatalf("Want sniffed line ending CR, got %q (%v)", d.Line_ending, err)
+	}
+}
+
+func (e test_error) verify(t *testing.T){
+	r := e.reader(t)
+	_, err := r.Bytes([]byte(e.input), "")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	
+	if err.Error() != e.error {
+		t.Fatalf("Expected error '%s', got '%v'", e.error, err)
+	}
+	if !errors.Is(err, e.is) {
+		t.Fatalf("Expected error code '%s', got '%v'", e.is.(*Error).Code(), err)
+	}
+	var cerr *Error
+	if !errors.As(err, &cerr) {
+		t.Fatalf("Expected *Error, got %T", err)
+	}
+	if cerr.Line != e.line || cerr.Column != e.column {
+		t.Fatalf("Expected position %d:%d, got %d:%d", e.line, e.column, cerr.Line, cerr.Column)
+	}
+	
+	fmt.Println(strings.Join(r.Log(), "\n"))
+}
+
+func (o test_output) verify(t *testing.T){
+	r := o.reader(t)
+	out, err := r.Bytes([]byte(o.input), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	
+	header := strings.Join(out.Header, ",")
+	if header != o.header {
+		t.Fatalf("Want: %s\n\nGot: %s", o.header, header)
+	}
+	
+	s := make([]string, len(out.Rows))
+	for i, line := range out.Rows {
+		s[i] = strings.Join(line.Row, ",")
+	}
+	
+	rows := strings.Join(s, "\n")
+	if rows != o.rows {
+		t.Fatalf("Want: %s\n\nGot: %s", o.rows, rows)
+	}
+	
+	fmt.Println(strings.Join(r.Log(), "\n"))
+}
+
+func verify_test[T tester](t *testing.T, tests []T){
+	for i, tt := range tests {
+		fmt.Println("test:", i, "\n---")
+		tt.verify(t)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,146 @@
+package csv
+
+import (
+	"fmt"
+	"time"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type (
+	//	Declared columns a table is validated against
+	Schema []Schema_column
+	
+	//	Declared column (min/max apply to the value of numbers and the length of text)
+	Schema_column struct {
+		Name		string
+		Aliases		[]string
+		Required	bool
+		Type		string
+		Decimal		rune
+		Layout		string
+		Pattern		*regexp.Regexp
+		Min			*float64
+		Max			*float64
+		Values		[]string
+	}
+	
+	Violation struct {
+		Line	int		`json:"line"`
+		Column	string	`json:"column"`
+		Value	string	`json:"value"`
+		Message	string	`json:"message"`
+	}
+)
+
+//	Validate table against schema and return every violation (line is -1 for missing columns)
+func (s Schema) Validate(t Table) []Violation {
+	var violations []Violation
+	for _, col := range s {
+		c := col.index(t)
+		if c == -1 {
+			if col.Required {
+				violations = append(violations, Violation{
+					Line:		-1,
+					Column:		col.Name,
+					Message:	"Column missing",
+				})
+			}
+			continue
+		}
+		
+		values := t.column(c)
+		decimal := col.Decimal
+		if decimal == 0 && (col.Type == TYPE_INT || col.Type == TYPE_DECIMAL) {
+			if decimal = infer_column(values).Decimal; decimal == 0 {
+				decimal = ','
+			}
+		}
+		for i, value := range values {
+			if msg := col.check(strings.TrimSpace(value), decimal); msg != "" {
+				violations = append(violations, Violation{
+					Line:		t.Rows[i].Line,
+					Column:		col.Name,
+					Value:		value,
+					Message:	msg,
+				})
+			}
+		}
+	}
+	return violations
+}
+
+//	Find column by name or alias
+func (col Schema_column) index(t Table) int {
+	for _, name := range append([]string{col.Name}, col.Aliases...) {
+		if c := t.Index(name); c != -1 {
+			return c
+		}
+	}
+	return -1
+}
+
+func (col Schema_column) check(value string, decimal rune) string {
+	if value == "" {
+		if col.Required {
+			return "Value required"
+		}
+		return ""
+	}
+	
+	var (
+		size	float64
+		sized	bool
+	)
+	switch col.Type {
+	case TYPE_INT, TYPE_DECIMAL:
+		n, ok := parse_number(value, decimal)
+		if !ok || col.Type == TYPE_INT && (n.decimal != 0 || n.percent) {
+			return "Invalid "+col.type_name()
+		}
+		size, _	= strconv.ParseFloat(n.value, 64)
+		sized	= true
+	case TYPE_BOOL:
+		if _, ok := parse_bool(value); !ok {
+			return "Invalid boolean"
+		}
+	case TYPE_DATE, TYPE_DATETIME:
+		if col.Layout != "" {
+			if _, err := time.Parse(col.Layout, value); err != nil {
+				return "Invalid "+col.type_name()
+			}
+		} else if _, tm, ok := date_layout(value); !ok || col.Type == TYPE_DATE && tm != "" {
+			return "Invalid "+col.type_name()
+		}
+	default:
+		size	= float64(utf8.RuneCountInString(value))
+		sized	= true
+	}
+	
+	if col.Pattern != nil && !col.Pattern.MatchString(value) {
+		return "Value does not match pattern"
+	}
+	if sized && col.Min != nil && size < *col.Min {
+		return fmt.Sprintf("Value below minimum: %g", *col.Min)
+	}
+	if sized && col.Max != nil && size > *col.Max {
+		return fmt.Sprintf("Value above maximum: %g", *col.Max)
+	}
+	if len(col.Values) != 0 && !slices.Contains(col.Values, value) {
+		return "Value not allowed: "+value
+	}
+	return ""
+}
+
+func (col Schema_column) type_name() string {
+	switch col.Type {
+	case TYPE_INT:
+		return "integer"
+	case TYPE_DATETIME:
+		return "date and time"
+	}
+	return col.Type
+}
\ No newline at end of file
@@ -0,0 +1,44 @@
+package csv
+
+import (
+	"regexp"
+	"testing"
+)
+
+func Test_schema(t *testing.T){
+	input := `Navn;Beløb;Dato;Status
+Anna;1.234,50;31-01-2024;paid
+;abc;2024-13-45;open
+Carl;-5;01-02-2024;void`
+	out, err := NewReader("").Bytes([]byte(input), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	
+	zero := 0.0
+	schema := Schema{
+		{Name: "Name", Aliases: []string{"Navn"}, Required: true, Pattern: regexp.MustCompile(`^\p{Lu}`)},
+		{Name: "Amount", Aliases: []string{"Beløb"}, Type: TYPE_DECIMAL, Min: &zero},
+		{Name: "Date", Aliases: []string{"Dato"}, Type: TYPE_DATE, Layout: "02-01-2006"},
+		{Name: "Status", Values: []string{"paid", "open"}},
+		{Name: "Account", Required: true},
+	}
+	
+	want := []Violation{
+		{3, "Name", "", "Value required"},
+		{3, "Amount", "abc", "Invalid decimal"},
+		{4, "Amount", "-5", "Value below minimum: 0"},
+		{3, "Date", "2024-13-45", "Invalid date"},
+		{4, "Status", "void", "Value not allowed: void"},
+		{-1, "Account", "", "Column missing"},
+	}
+	got := schema.Validate(out)
+	if len(got) != len(want) {
+		t.Fatalf("Want %d violations, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Violation %d want: %+v\n\nGot: %+v", i, want[i], got[i])
+		}
+	}
+}
\ No newline at end of file
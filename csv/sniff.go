@@ -0,0 +1,186 @@
+package csv
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+	"encoding/csv"
+)
+
+const (
+	//	Records scored per separator candidate
+	sniff_records = 1000
+	
+	LINE_ENDING_LF		= "LF"
+	LINE_ENDING_CRLF	= "CRLF"
+	LINE_ENDING_CR		= "CR"
+	LINE_ENDING_MIXED	= "mixed"
+)
+
+//	Detected CSV format
+type Dialect struct {
+	Encoding	string	`json:"encoding"`
+	Bom			bool	`json:"bom"`
+	Separator	rune	`json:"separator"`
+	Quote		rune	`json:"quote"`
+	Line_ending	string	`json:"line_ending"`
+	Header		bool	`json:"header"`
+	Columns		int		`json:"columns"`
+	Confidence	float64	`json:"confidence"`
+}
+
+//	Sniff dialect from a bounded prefix of the source
+func Sniff(b []byte) (Dialect, error){
+	r := NewReader("")
+	eof		:= len(b) <= sniff_size
+	window	:= b[:min(len(b), sniff_size)]
+	src		:= r.detect_encoding(window, !eof)
+	
+	d := Dialect{
+		Encoding:	r.charset,
+		Bom:		len(src) != len(window),
+	}
+	
+	s := r.sniff_text(src, eof)
+	if err := r.src_encoding(s); err != nil {
+		return d, err
+	}
+	d.Separator		= r.separator
+	d.Confidence	= r.sep_confidence
+	d.Line_ending	= r.line_ending
+	d.Columns, _	= sniff_consistency(count_fields(s, r.separator, sniff_records))
+	
+	read := csv.NewReader(strings.NewReader(s))
+	read.FieldsPerRecord	= -1
+	read.Comma				= r.separator
+	read.LazyQuotes			= true
+	for {
+		line, err := read.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil || !trim_line(line) {
+			continue
+		}
+		d.Header = header_error(line) == nil
+		break
+	}
+	
+	if strings.ContainsRune(s, '"') {
+		d.Quote = '"'
+	}
+	return d, nil
+}
+
+//	Replay a sniffed or stored dialect
+func (r *Reader) Dialect(d Dialect) *Reader {
+	if d.Encoding != "" {
+		r.Charset(d.Encoding)
+	}
+	if d.Separator != 0 {
+		r.Separator(d.Separator)
+	}
+	if !d.Header {
+		r.Ignore_header()
+	}
+	return r
+}
+
+//	Find the separator giving the most consistent field count per record (confidence is the share of records with that count)
+func sniff_separator(s string, seps []rune) (rune, float64, bool){
+	var (
+		best		rune
+		best_fields	int
+		confidence	float64
+	)
+	for _, sep := range seps {
+		fields, consistency := sniff_consistency(count_fields(s, sep, sniff_records))
+		if fields < 2 {
+			continue
+		}
+		if consistency > confidence || consistency == confidence && fields > best_fields {
+			best		= sep
+			best_fields	= fields
+			confidence	= consistency
+		}
+	}
+	return best, confidence, best != 0
+}
+
+//	Most frequent field count and its share of records
+func sniff_consistency(counts []int) (int, float64){
+	if len(counts) == 0 {
+		return 0, 0
+	}
+	
+	freq := map[int]int{}
+	for _, c := range counts {
+		freq[c]++
+	}
+	
+	var mode, max int
+	for c, n := range freq {
+		if n > max || n == max && c > mode {
+			mode	= c
+			max		= n
+		}
+	}
+	return mode, float64(max) / float64(len(counts))
+}
+
+//	Count fields per record (separators and newlines inside quoted fields are ignored)
+func count_fields(s string, sep rune, max_records int) []int {
+	var (
+		counts		[]int
+		fields		= 1
+		quoted		bool
+		field_start	= true
+		empty		= true
+	)
+	//	Decode runes as needed since only the first records are counted
+	for i := 0; i < len(s) && len(counts) < max_records; {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if quoted {
+			if c == '"' {
+				//	Escaped quote
+				if i < len(s) && s[i] == '"' {
+					i++
+					continue
+				}
+				quoted = false
+			}
+			continue
+		}
+		
+		switch c {
+		case '"':
+			if field_start {
+				quoted = true
+			}
+			field_start	= false
+			empty		= false
+		case sep:
+			fields++
+			field_start	= true
+			empty		= false
+		case '\n':
+			if !empty {
+				counts = append(counts, fields)
+			}
+			fields		= 1
+			field_start	= true
+			empty		= true
+		default:
+			field_start	= false
+			empty		= false
+		}
+	}
+	
+	//	Last record without newline
+	if !empty && len(counts) < max_records {
+		counts = append(counts, fields)
+	}
+	return slices.Clip(counts)
+}
\ No newline at end of file
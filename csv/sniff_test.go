@@ -0,0 +1,101 @@
+package csv
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func Test_sniff(t *testing.T){
+	tests := []struct{
+		input	string
+		dialect	Dialect
+	}{{
+		input:	BOM_UTF8+"Name;Amount;Note\r\n\"Hansen, Jens\";\"1,50\";x\r\nOlsen;2;y\r\n",
+		dialect:	Dialect{
+			Encoding:		charset_utf8,
+			Bom:			true,
+			Separator:		';',
+			Quote:			'"',
+			Line_ending:	LINE_ENDING_CRLF,
+			Header:			true,
+			Columns:		3,
+			Confidence:		1,
+		},
+	},{
+		input:	"1,K\xf8benhavn\n2,\xc6bel\xf8\n",
+		dialect:	Dialect{
+			Encoding:		charset_windows1252,
+			Separator:		',',
+			Line_ending:	LINE_ENDING_LF,
+			Columns:		2,
+			Confidence:		1,
+		},
+	}}
+	for _, tt := range tests {
+		d, err := Sniff([]byte(tt.input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if d != tt.dialect {
+			t.Fatalf("Want: %+v\n\nGot: %+v", tt.dialect, d)
+		}
+		
+		//	Replay dialect
+		r := NewReader("").Dialect(d)
+		out, err := r.Bytes([]byte(tt.input), "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(out.Header) != 0 != d.Header || len(out.Rows[0].Row) != d.Columns {
+			t.Fatalf("Dialect not replayed: %+v", out)
+		}
+		fmt.Println(strings.Join(r.Log(), "\n"))
+	}
+	
+	if _, err := Sniff(nil); err == nil || err.Error() != "CSV empty" {
+		t.Fatalf("Expected error 'CSV empty', got '%v'", err)
+	}
+}
+
+func Test_sniff_separator(t *testing.T){
+	tests := []struct{
+		input	string
+		sep		rune
+	}{{
+		//	Quoted commas in a semicolon file
+		input:	"Name;Address;Amount\n\"Hansen, Jens\";\"Vej 1, 2. th\";\"1,234.00\"\n\"Olsen, Bo\";\"Gade 2, st\";\"2,000.50\"",
+		sep:	';',
+	},{
+		//	Multi-line quoted cell
+		input:	"Name,Note\n\"Jens\",\"line 1\nline 2, more\"\n\"Bo\",\"ok\"",
+		sep:	',',
+	},{
+		input:	"a\tb\tc\n1\t2\t3\n4\t5\t6",
+		sep:	'\t',
+	}}
+	for _, tt := range tests {
+		sep, confidence, ok := sniff_separator(tt.input, separators)
+		if !ok || sep != tt.sep {
+			t.Fatalf("Want separator %q, got %q", tt.sep, sep)
+		}
+		if confidence != 1 {
+			t.Fatalf("Want confidence 1, got %.2f", confidence)
+		}
+	}
+	
+	if _, _, ok := sniff_separator("head1\ntest1", separators); ok {
+		t.Fatal("Expected no separator")
+	}
+	
+	//	Only the sniffed records are decoded
+	input := strings.Repeat("test1;test2\n", 1 << 20)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	sniff_separator(input, separators)
+	runtime.ReadMemStats(&after)
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > uint64(len(input)) {
+		t.Fatalf("Want less than %d bytes allocated, got %d", len(input), alloc)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,301 @@
+package csv
+
+import (
+	"io"
+	"fmt"
+	"errors"
+	"iter"
+	"bytes"
+	"bufio"
+	"strings"
+	"encoding/csv"
+	"github.com/clarkk/go-fmt/sanitize"
+)
+
+const (
+	//	Bytes held in memory for encoding and separator detection while streaming
+	sniff_size = 64 * 1024
+)
+
+type text_reader struct {
+	r 			*Reader
+	src			*bufio.Reader
+	transcoded	bool
+	buf			[]byte
+	err			error
+}
+
+//	Stream rows from reader
+//	Only a bounded window is held in memory for encoding and separator detection. Rows are padded to the length of the header (or the first row when there is no header)
+//	Rows longer than the first row fail with ErrTooFewHeaders (overflow columns are removed with Remove_overflow_cols() when the header is found or ignored)
+func (r *Reader) Rows(src io.Reader) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool){
+		r.log_options()
+		
+		if err := r.check_options(); err != nil {
+			yield(Row{}, err)
+			return
+		}
+		
+		if r.options[opt_remove_empty_cols] {
+			yield(Row{}, new_error(ErrOptions, "Option 'remove_empty_cols' can not be used when streaming", nil))
+			return
+		}
+		if r.options[opt_lenient] {
+			yield(Row{}, new_error(ErrOptions, "Option 'lenient' can not be used when streaming", nil))
+			return
+		}
+		
+		src = r.limits.reader(src)
+		window := make([]byte, sniff_size)
+		n, err := io.ReadFull(src, window)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			var lerr *Error
+			if errors.As(err, &lerr) {
+				yield(Row{}, r.log_fail(lerr))
+				return
+			}
+			yield(Row{}, new_error(ErrRead, "", err))
+			return
+		}
+		window	= window[:n]
+		eof		:= n < sniff_size
+		
+		window = r.detect_encoding(window, !eof)
+		
+		//	Quotes still open at the end of the window are taken as unbalanced
+		if err := r.src_encoding(r.sniff_text(window, eof)); err != nil {
+			yield(Row{}, r.log_fail(err))
+			return
+		}
+		//	Non-printable chars are collected line by line as the text is read
+		r.non_printable = ""
+		
+		lines := newLine_index(r.charset, int64(n - len(window)), r.unbalanced)
+		body := io.Reader(&line_reader{io.MultiReader(bytes.NewReader(window), src), lines})
+		text := &text_reader{
+			r:	r,
+		}
+		//	UTF16 must be transcoded before lines can be split
+		if r.charset == charset_utf16le || r.charset == charset_utf16be {
+			body			= newUtf16_reader(body, r.charset == charset_utf16be)
+			text.transcoded	= true
+		}
+		text.src = bufio.NewReader(newEol_reader(body, &eol{unbalanced: r.unbalanced}))
+		
+		read := csv.NewReader(text)
+		read.FieldsPerRecord	= -1
+		read.Comma				= r.separator
+		
+		var (
+			pos			= row_pos{lines: lines}
+			records		int
+			cols		int
+			count		int
+			replaced	int
+		)
+		for {
+			line, err := read.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				var lerr *Error
+				if errors.As(err, &lerr) {
+					yield(Row{}, r.log_fail(lerr))
+					return
+				}
+				e := parse_error(err)
+				if r.non_printable != "" {
+					r.log_non_printable()
+					yield(Row{}, new_error(ErrInvalidEncoding, "", nil).at(e.Line, 0))
+					return
+				}
+				yield(Row{}, r.log_fail(e))
+				return
+			}
+			
+			row := pos.row(read, line)
+			records++
+			if err := r.limits.record(records, line, row.Line); err != nil {
+				yield(Row{}, r.log_fail(err))
+				return
+			}
+			if !trim_line(line) {
+				continue
+			}
+			
+			//	First row determines header and columns
+			if cols == 0 {
+				if err := r.stream_header(row.Line, line); err != nil {
+					yield(Row{}, err)
+					return
+				}
+				if len(r.out_header) != 0 {
+					cols = len(r.out_header)
+					continue
+				}
+				cols = len(line)
+			}
+			
+			if len(line) != cols {
+				if r.options[opt_col_integrity] {
+					yield(Row{}, r.log_fail(new_error(ErrColumnsNotEqual, "", nil).at(row.Line, 0)))
+					return
+				}
+				
+				//	Without a column header the columns are determined by the first row
+				if len(line) > cols {
+					if !r.options[opt_remove_overflow_cols] || (len(r.out_header) == 0 && !r.options[opt_ignore_header]) {
+						yield(Row{}, r.log_fail(new_error(ErrTooFewHeaders, "", nil).at(row.Line, cols + 1)))
+						return
+					}
+					r.log_append(log_warning(LOG_OVERFLOW_REMOVED, fmt.Sprintf("Remove overflow columns line: %d", row.Line)).line(row.Line))
+					line = line[:cols]
+				}
+				
+				if len(line) < cols {
+					r.log_append(log_warning(LOG_ROW_FILLED, fmt.Sprintf("Fill empty columns line: %d", row.Line)).line(row.Line))
+					for len(line) < cols {
+						line = append(line, "")
+					}
+				}
+			}
+			
+			if r.non_printable != "" {
+				replaced += strip_non_printable_line(line)
+			}
+			
+			count++
+			row.Row = line
+			if !yield(row, nil) {
+				return
+			}
+		}
+		
+		if count == 0 {
+			yield(Row{}, r.log_fail(new_error(ErrEmpty, "", nil)))
+			return
+		}
+		
+		if r.non_printable != "" {
+			r.log_append(log_warning(LOG_VALUES_REPLACED, fmt.Sprintf("Values replaced (non-printable): %d", replaced)).count(replaced))
+		}
+		r.log_append(log_info(LOG_ROWS, fmt.Sprintf("Rows found: %d", count)).count(count))
+	}
+}
+
+//	Check first streamed row for column header
+func (r *Reader) stream_header(l int, line []string) error {
+	if err := r.one_col_error(len(line)); err != nil {
+		return err
+	}
+	
+	if r.options[opt_ignore_header] {
+		return nil
+	}
+	
+	r.checked_header = true
+	if err := header_error(line); err != nil {
+		if r.options[opt_optional_header] {
+			return nil
+		}
+		err.Line = l
+		return r.log_fail(err)
+	}
+	
+	if r.non_printable != "" {
+		strip_non_printable_line(line)
+	}
+	
+	r.log_append(log_info(LOG_HEADER, "Column headers found"))
+	r.out_header = line
+	return nil
+}
+
+//	Decode sniffing window (the last line is dropped if the window is partial)
+func (r *Reader) sniff_text(window []byte, eof bool) string {
+	s := r.eol_normalize(r.decode(window))
+	if !eof {
+		if i := strings.LastIndexByte(s, '\n'); i != -1 {
+			s = s[:i+1]
+		}
+	}
+	s = sanitize.Filter_utf8mb3(s)
+	return sanitize.Trim(s, true)
+}
+
+func (t *text_reader) Read(p []byte) (int, error){
+	for len(t.buf) == 0 {
+		if t.err != nil {
+			return 0, t.err
+		}
+		
+		line, err := t.src.ReadBytes('\n')
+		t.err = err
+		if len(line) == 0 {
+			continue
+		}
+		
+		newline := line[len(line)-1] == '\n'
+		if newline {
+			line = line[:len(line)-1]
+		}
+		
+		var s string
+		if t.transcoded {
+			s = sanitize_line(string(line))
+		} else {
+			s = sanitize_line(t.r.decode(line))
+		}
+		if np := sanitize.Non_printable(s); np != "" {
+			t.r.non_printable += np
+		}
+		t.buf = append(t.buf[:0], s...)
+		if newline {
+			t.buf = append(t.buf, '\n')
+		}
+	}
+	
+	n := copy(p, t.buf)
+	t.buf = t.buf[n:]
+	return n, nil
+}
+
+//	Sanitize line (CR is only left in quoted cells and is kept)
+func sanitize_line(s string) string {
+	s = sanitize.Filter_utf8mb3(s)
+	if !strings.Contains(s, "\r") {
+		return strings.TrimSpace(sanitize.Trim(s, true))
+	}
+	parts := strings.Split(s, "\r")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(sanitize.Trim(part, true))
+	}
+	return strings.Join(parts, "\r")
+}
+
+//	Trim values and report if line has any values
+func trim_line(line []string) bool {
+	empty_line := true
+	for c, col := range line {
+		col = strings.TrimSpace(col)
+		if col != "" {
+			empty_line = false
+		}
+		line[c] = col
+	}
+	return !empty_line
+}
+
+func strip_non_printable_line(line []string) int {
+	c := 0
+	for i, value := range line {
+		s := sanitize.Strip_non_printable(value)
+		if s != value {
+			line[i] = s
+			c++
+		}
+	}
+	return c
+}
\ No newline at end of file
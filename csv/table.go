@@ -0,0 +1,83 @@
+package csv
+
+import (
+	"iter"
+	"strings"
+)
+
+//	Number of rows
+func (t Table) Len() int {
+	return len(t.Rows)
+}
+
+//	Get index of column by header name (exact match first, then case-insensitive) or -1 if not found
+func (t Table) Index(name string) int {
+	return header_index(t.Header, name)
+}
+
+//	Get all values in column by header name (nil if not found)
+func (t Table) Column(name string) []string {
+	c := t.Index(name)
+	if c == -1 {
+		return nil
+	}
+	return t.column(c)
+}
+
+func (t Table) column(c int) []string {
+	values := make([]string, len(t.Rows))
+	for i, row := range t.Rows {
+		if c < len(row.Row) {
+			values[i] = row.Row[c]
+		}
+	}
+	return values
+}
+
+//	Get value in row by header name (empty if row or column not found)
+func (t Table) Get(i int, name string) string {
+	if i < 0 || i >= len(t.Rows) {
+		return ""
+	}
+	c := t.Index(name)
+	if c == -1 || c >= len(t.Rows[i].Row) {
+		return ""
+	}
+	return t.Rows[i].Row[c]
+}
+
+//	Iterate rows as records keyed by header name (cells without a header are left out)
+func (t Table) Records() iter.Seq2[int, map[string]string] {
+	return func(yield func(int, map[string]string) bool){
+		for i, row := range t.Rows {
+			record := make(map[string]string, len(t.Header))
+			for c, name := range t.Header {
+				if _, ok := record[name]; ok {
+					continue
+				}
+				if c < len(row.Row) {
+					record[name] = row.Row[c]
+				} else {
+					record[name] = ""
+				}
+			}
+			if !yield(i, record) {
+				return
+			}
+		}
+	}
+}
+
+func header_index(header Header, name string) int {
+	for i, h := range header {
+		if h == name {
+			return i
+		}
+	}
+	for i, h := range header {
+		if strings.EqualFold(h, name) {
+			return i
+		}
+	}
+	return -1
+}
\ No newline at end of file
@@ -0,0 +1,43 @@
+package csv
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_table(t *testing.T){
+	out, err := NewReader("").Bytes([]byte("Name;Amount;Note\nA;1;x\nB;2\nC;3;z"), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	
+	if out.Len() != 3 {
+		t.Fatalf("Want 3 rows, got %d", out.Len())
+	}
+	if i := out.Index("amount"); i != 1 {
+		t.Fatalf("Want index 1, got %d", i)
+	}
+	if i := out.Index("Missing"); i != -1 {
+		t.Fatalf("Want index -1, got %d", i)
+	}
+	if got := out.Column("Name"); !slices.Equal(got, []string{"A", "B", "C"}) {
+		t.Fatalf("Want column A,B,C, got %v", got)
+	}
+	if got := out.Get(2, "Note"); got != "z" {
+		t.Fatalf("Want z, got %s", got)
+	}
+	if got := out.Get(5, "Note"); got != "" {
+		t.Fatalf("Want empty, got %s", got)
+	}
+	
+	var names []string
+	for i, record := range out.Records() {
+		if record["Amount"] != out.Rows[i].Row[1] {
+			t.Fatalf("Row %d want amount %s, got %s", i, out.Rows[i].Row[1], record["Amount"])
+		}
+		names = append(names, record["Name"])
+	}
+	if !slices.Equal(names, []string{"A", "B", "C"}) {
+		t.Fatalf("Want records A,B,C, got %v", names)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,263 @@
+package csv
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"errors"
+	"time"
+	"strings"
+	"strconv"
+	"github.com/clarkk/go-fmt/sanitize"
+)
+
+type workbook interface {
+	sheet_names() []string
+	rows(i int) ([][]string, error)
+}
+
+const (
+	//	Max rows and columns in a sheet (as in Excel)
+	sheet_rows	= 1048576
+	sheet_cols	= 16384
+	//	Max cells read from a sheet (repeated ODS rows and cells expand small files)
+	sheet_cells	= 16 << 20
+)
+
+var (
+	workbook_formats = map[string]string{
+		MIME_XLS:	"XLS",
+		MIME_XLXS:	"XLSX",
+		MIME_ODS:	"ODS",
+		MIME_HTML:	"HTML",
+	}
+	
+	excel_epoch			= time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	excel_epoch_1904	= time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+//	List sheets in XLS, XLSX or ODS workbook
+func Sheets(b []byte, mimetype string) ([]string, error){
+	wb, err := open_workbook(b, mimetype, &limits{})
+	if err != nil {
+		return nil, err
+	}
+	return wb.sheet_names(), nil
+}
+
+func open_workbook(b []byte, mimetype string, lim *limits) (workbook, error){
+	var (
+		wb	workbook
+		err	error
+	)
+	switch mimetype {
+	case MIME_XLS:
+		wb, err = open_xls(b, lim)
+	case MIME_XLXS:
+		wb, err = open_xlsx(b, lim)
+	case MIME_ODS:
+		wb, err = open_ods(b, lim)
+	case MIME_HTML:
+		wb, err = open_html(b, lim)
+	default:
+		return nil, new_error(ErrWorkbook, "Only XLS, XLSX, ODS and HTML files have sheets", nil)
+	}
+	if err != nil {
+		var lerr *Error
+		if errors.As(err, &lerr) {
+			return nil, lerr
+		}
+		return nil, new_error(ErrWorkbook, "Unable to read "+workbook_formats[mimetype], err)
+	}
+	return wb, nil
+}
+
+//	Read rows from the selected sheet in workbook
+func (r *Reader) read_workbook(mimetype string) (Rows, error){
+	name := workbook_formats[mimetype]
+	wb, err := open_workbook(r.src, mimetype, &r.limits)
+	if err != nil {
+		var werr *Error
+		errors.As(err, &werr)
+		r.log_append(log_error(werr.code, err.Error()))
+		return nil, err
+	}
+	
+	r.sheets = wb.sheet_names()
+	if len(r.sheets) == 0 {
+		return nil, r.log_fail(new_error(ErrNoSheets, name+" has no sheets", nil))
+	}
+	r.log_append(log_info(LOG_SHEETS, "Sheets: "+strings.Join(r.sheets, ", ")).count(len(r.sheets)))
+	
+	if r.options[opt_auto_sheet] {
+		return r.auto_sheet(wb, name)
+	}
+	
+	i, err := r.sheet_select()
+	if err != nil {
+		return nil, err
+	}
+	
+	rows, err := r.read_sheet(wb, name, i)
+	if err != nil {
+		return nil, err
+	}
+	r.log_append(log_info(LOG_SHEET, name+" sheet: "+r.sheets[i]))
+	return rows, nil
+}
+
+//	Select first non-empty sheet with column header
+func (r *Reader) auto_sheet(wb workbook, name string) (Rows, error){
+	for i, sheet := range r.sheets {
+		rows, err := r.read_sheet(wb, name, i)
+		if err != nil {
+			return nil, err
+		}
+		
+		for _, row := range rows {
+			line := row.Row
+			if !trim_line(line) {
+				continue
+			}
+			//	Cover sheets usually have a single title cell
+			if len(line) < 2 {
+				break
+			}
+			if r.options[opt_ignore_header] || r.options[opt_optional_header] || header_error(line) == nil {
+				r.log_append(log_info(LOG_SHEET, name+" sheet (auto): "+sheet))
+				return rows, nil
+			}
+			break
+		}
+	}
+	return nil, r.log_fail(new_error(ErrSheetNotFound, "No sheet with column headers found", nil))
+}
+
+func (r *Reader) sheet_select() (int, error){
+	if r.sheet == "" {
+		if r.sheet_index < 0 || r.sheet_index >= len(r.sheets) {
+			return 0, r.log_fail(new_error(ErrSheetNotFound, fmt.Sprintf("Sheet index out of range: %d", r.sheet_index), nil))
+		}
+		return r.sheet_index, nil
+	}
+	
+	if i := slices.Index(r.sheets, r.sheet); i != -1 {
+		return i, nil
+	}
+	for i, sheet := range r.sheets {
+		if strings.EqualFold(sheet, r.sheet) {
+			return i, nil
+		}
+	}
+	return 0, r.log_fail(new_error(ErrSheetNotFound, "Sheet not found: "+r.sheet, nil))
+}
+
+//	Read sheet rows (the line is the sheet row number)
+func (r *Reader) read_sheet(wb workbook, name string, i int) (Rows, error){
+	lines, err := wb.rows(i)
+	if err != nil {
+		var lerr *Error
+		if errors.As(err, &lerr) {
+			return nil, r.log_fail(lerr)
+		}
+		return nil, r.log_fail(new_error(ErrWorkbook, "Unable to read "+name, err))
+	}
+	
+	var (
+		non_printable	strings.Builder
+		rows			= make(Rows, len(lines))
+	)
+	for l, line := range lines {
+		if err := r.limits.converting_at(l); err != nil {
+			var lerr *Error
+			errors.As(err, &lerr)
+			return nil, r.log_fail(lerr)
+		}
+		for c, value := range line {
+			value = sanitize.Filter_utf8mb3(value)
+			value = sanitize.Trim(value, true)
+			non_printable.WriteString(sanitize.Non_printable(value))
+			line[c] = value
+		}
+		rows[l] = Row{
+			Line:		l + 1,
+			Line_end:	l + 1,
+			Row:		line,
+		}
+	}
+	r.non_printable = non_printable.String()
+	return rows, nil
+}
+
+//	Convert Excel serial date to ISO date (and time if any)
+func excel_date(serial float64, date1904 bool) string {
+	var t time.Time
+	if date1904 {
+		t = excel_epoch_1904
+	} else {
+		//	Excel treats 1900 as a leap year
+		if serial >= 1 && serial < 60 {
+			serial++
+		}
+		t = excel_epoch
+	}
+	
+	days := math.Floor(serial)
+	secs := math.Round((serial - days) * 86400)
+	t = t.AddDate(0, 0, int(days)).Add(time.Duration(secs) * time.Second)
+	
+	switch {
+	case days == 0 && secs != 0:
+		return t.Format(time.TimeOnly)
+	case secs == 0:
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(time.DateTime)
+}
+
+//	Format number without float artifacts
+func excel_number(f float64) string {
+	f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', 15, 64), 64)
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+//	Check if built-in or custom number format is a date format
+func excel_date_format(id int, code string) bool {
+	switch {
+	case id >= 14 && id <= 22,
+		id >= 27 && id <= 36,
+		id >= 45 && id <= 47,
+		id >= 50 && id <= 58:
+		return true
+	case code == "":
+		return false
+	}
+	
+	var (
+		quoted	bool
+		bracket	bool
+		escaped	bool
+	)
+	for _, c := range strings.ToLower(code) {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted:
+			quoted = c != '"'
+		case bracket:
+			bracket = c != ']'
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = true
+		case c == '[':
+			bracket = true
+		case c == ';':
+			//	Only the first section decides
+			return false
+		case c == 'y', c == 'd', c == 'h', c == 's', c == 'm':
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
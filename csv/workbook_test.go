@@ -0,0 +1,401 @@
+package csv
+
+import (
+	"fmt"
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"archive/zip"
+	"encoding/binary"
+)
+
+const (
+	test_xlsx_workbook = `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+	<sheets>%s</sheets>
+</workbook>`
+	test_xlsx_rels = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">%s</Relationships>`
+	test_xlsx_styles = `<?xml version="1.0" encoding="UTF-8"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<numFmts count="1"><numFmt numFmtId="164" formatCode="dd/mm/yyyy"/></numFmts>
+	<cellXfs count="4"><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/><xf numFmtId="20"/></cellXfs>
+</styleSheet>`
+	test_xlsx_strings = `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<si><t>Name</t></si>
+	<si><t>Date</t></si>
+	<si><r><t>Amo</t></r><r><t>unt</t></r></si>
+	<si><t>Ærø</t><rPh><t>ignored</t></rPh></si>
+</sst>`
+	test_xlsx_sheet = `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>%s</sheetData></worksheet>`
+)
+
+func Test_xlsx(t *testing.T){
+	b := test_xlsx(map[string]string{
+		"Data": `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c></row>
+<row r="2"><c r="A2" t="s"><v>3</v></c><c r="B2" s="1"><v>45322</v></c><c r="C2"><v>1234.5</v></c></row>
+<row r="4"><c r="A4" t="inlineStr"><is><t>Inline</t></is></c><c r="B4" s="2"><v>45322.5</v></c><c r="C4"><v>0.30000000000000004</v></c></row>
+<row r="5"><c r="A5" t="b"><v>1</v></c><c r="B5" s="3"><v>0.5</v></c><c r="C5" t="str"><f>A1</f><v>calc</v></c></row>`,
+	})
+	
+	r := NewReader("")
+	out, err := r.Bytes(b, MIME_XLXS)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	verify_table(t, out, "Name,Date,Amount", "Ærø,2024-01-31,1234.5\nInline,2024-01-31 12:00:00,0.3\nTRUE,12:00:00,calc")
+	
+	lines := []int{2, 4, 5}
+	for i, row := range out.Rows {
+		if row.Line != lines[i] {
+			t.Fatalf("Row %d want line %d, got %d", i, lines[i], row.Line)
+		}
+	}
+	
+	fmt.Println(strings.Join(r.Log(), "\n"))
+	
+	//	References beyond the sheet limits are rejected before rows are allocated
+	for _, sheet := range []string{
+		`<row r="1"><c r="A1"><v>1</v></c><c r="B1"><v>2</v></c></row><row r="40000000"><c r="A40000000"><v>1</v></c></row>`,
+		`<row r="1"><c r="A1"><v>1</v></c><c r="XFE1"><v>2</v></c></row>`,
+		`<row r="1"><c r="A1"><v>1</v></c><c r="ZZZZZZZZZZZZZZ1"><v>2</v></c></row>`,
+	} {
+		_, err := NewReader("").Optional_header().Bytes(test_xlsx(map[string]string{"Data": sheet}), MIME_XLXS)
+		if !errors.Is(err, ErrWorkbook) {
+			t.Fatalf("Expected workbook error, got %v", err)
+		}
+	}
+}
+
+func Test_xls(t *testing.T){
+	var (
+		cells	[]byte
+		le		= binary.LittleEndian
+	)
+	cell := func(id uint16, row, col, style int, data ...any) []byte {
+		b := le.AppendUint16(nil, uint16(row))
+		b = le.AppendUint16(b, uint16(col))
+		b = le.AppendUint16(b, uint16(style))
+		for _, v := range data {
+			b, _ = binary.Append(b, le, v)
+		}
+		return test_biff(id, b)
+	}
+	cells = append(cells, cell(biff_labelsst, 0, 0, 0, uint32(0))...)
+	cells = append(cells, cell(biff_labelsst, 0, 1, 0, uint32(1))...)
+	cells = append(cells, cell(biff_labelsst, 0, 2, 0, uint32(2))...)
+	cells = append(cells, cell(biff_labelsst, 1, 0, 0, uint32(3))...)
+	cells = append(cells, cell(biff_rk, 1, 1, 1, uint32(45322 << 2 | 2))...)
+	cells = append(cells, cell(biff_number, 1, 2, 0, 1234.5)...)
+	cells = append(cells, cell(biff_formula, 3, 0, 0, []byte{0, 0, 0, 0, 0, 0, 0xFF, 0xFF}, make([]byte, 6))...)
+	cells = append(cells, test_biff(biff_string, test_xls_string("calc", 2))...)
+	//	MULRK: row, first col, (style, rk) pairs, last col
+	mulrk := le.AppendUint16(nil, 3)
+	mulrk = le.AppendUint16(mulrk, 1)
+	mulrk = le.AppendUint16(mulrk, 2)
+	mulrk = le.AppendUint32(mulrk, 45322 << 2 | 2)
+	mulrk = le.AppendUint16(mulrk, 0)
+	mulrk = le.AppendUint32(mulrk, 1234 << 2 | 3)
+	mulrk = le.AppendUint16(mulrk, 2)
+	cells = append(cells, test_biff(biff_mulrk, mulrk)...)
+	cells = append(cells, cell(biff_number, 4, 1, 3, 0.5)...)
+	
+	b := test_xls(cells, []string{"Name", "Date", "Amount", "Ærø"})
+	
+	r := NewReader("")
+	out, err := r.Bytes(b, MIME_XLS)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	verify_table(t, out, "Name,Date,Amount", "Ærø,2024-01-31,1234.5\ncalc,2024-01-31,12.34\n,12:00:00,")
+	
+	fmt.Println(strings.Join(r.Log(), "\n"))
+}
+
+func Test_ods(t *testing.T){
+	b := test_ods(map[string]string{
+		"Cover":	`<table:table-row><table:table-cell/></table:table-row>`,
+		"Data":		`<table:table-row>
+	<table:table-cell office:value-type="string"><text:p>Name</text:p></table:table-cell>
+	<table:table-cell office:value-type="string"><text:p>Date</text:p></table:table-cell>
+	<table:table-cell office:value-type="string"><text:p>Amount</text:p></table:table-cell>
+	<table:table-cell table:number-columns-repeated="16381"/>
+</table:table-row>
+<table:table-row>
+	<table:table-cell office:value-type="string"><text:p>Ærø<text:s text:c="2"/>by</text:p><office:annotation><text:p>note</text:p></office:annotation></table:table-cell>
+	<table:table-cell office:value-type="date" office:date-value="2024-01-31"><text:p>31-01-2024</text:p></table:table-cell>
+	<table:table-cell office:value-type="float" office:value="1234.5"><text:p>1.234,50</text:p></table:table-cell>
+</table:table-row>
+<table:table-row table:number-rows-repeated="2">
+	<table:table-cell table:number-columns-repeated="2" office:value-type="float" office:value="1"><text:p>1</text:p></table:table-cell>
+	<table:table-cell office:value-type="boolean" office:boolean-value="true"><text:p>TRUE</text:p></table:table-cell>
+</table:table-row>
+<table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="16384"/></table:table-row>`,
+	})
+	
+	out, err := NewReader("").Sheet("data").Bytes(b, MIME_ODS)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	verify_table(t, out, "Name,Date,Amount", "Ærø by,2024-01-31,1234.5\n1,1,TRUE\n1,1,TRUE")
+	
+	//	Repeats beyond the sheet limits are rejected before rows are allocated
+	for _, sheet := range []string{
+		`<table:table-row table:number-rows-repeated="40000000"><table:table-cell office:value-type="float" office:value="1"><text:p>1</text:p></table:table-cell><table:table-cell/></table:table-row>`,
+		`<table:table-row table:number-rows-repeated="1048575"><table:table-cell/></table:table-row><table:table-row table:number-rows-repeated="2"><table:table-cell office:value-type="float" office:value="1"><text:p>1</text:p></table:table-cell><table:table-cell/></table:table-row>`,
+		`<table:table-row><table:table-cell/><table:table-cell table:number-columns-repeated="16384" office:value-type="float" office:value="1"><text:p>1</text:p></table:table-cell></table:table-row>`,
+		`<table:table-row table:number-rows-repeated="4000"><table:table-cell table:number-columns-repeated="16384" office:value-type="float" office:value="1"><text:p>1</text:p></table:table-cell></table:table-row>`,
+	} {
+		_, err := NewReader("").Optional_header().Bytes(test_ods(map[string]string{"Data": sheet}), MIME_ODS)
+		if !errors.Is(err, ErrWorkbook) {
+			t.Fatalf("Expected workbook error, got %v", err)
+		}
+	}
+}
+
+func Test_sheets(t *testing.T){
+	b := test_xlsx(map[string]string{
+		"A Cover":	`<row r="1"><c r="A1" t="inlineStr"><is><t>Report 2024</t></is></c></row>`,
+		"B Data":	`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>2</v></c></row><row r="2"><c r="A2" t="s"><v>3</v></c><c r="B2"><v>10</v></c></row>`,
+	})
+	
+	sheets, err := Sheets(b, MIME_XLXS)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := strings.Join(sheets, ","); got != "A Cover,B Data" {
+		t.Fatalf("Want: A Cover,B Data\n\nGot: %s", got)
+	}
+	
+	tests := []func() *Reader{
+		func() *Reader {
+			return NewReader("").Sheet("B Data")
+		},
+		func() *Reader {
+			return NewReader("").Sheet_index(1)
+		},
+		func() *Reader {
+			return NewReader("").Auto_sheet()
+		},
+	}
+	for _, reader := range tests {
+		r := reader()
+		out, err := r.Bytes(b, MIME_XLXS)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		verify_table(t, out, "Name,Amount", "Ærø,10")
+		fmt.Println(strings.Join(r.Log(), "\n"))
+	}
+	
+	if _, err := NewReader("").Sheet("Missing").Bytes(b, MIME_XLXS); err == nil || err.Error() != "Sheet not found: Missing" {
+		t.Fatalf("Expected error 'Sheet not found: Missing', got '%v'", err)
+	}
+}
+
+func Test_excel_date_format(t *testing.T){
+	tests := map[string]bool{
+		"General":			false,
+		"0.00":				false,
+		"dd/mm/yyyy":		true,
+		"[$-409]d-mmm":		true,
+		`"day" 0`:			false,
+		`[Red]0.00`:		false,
+		`0;[Red]"m"0`:		false,
+	}
+	for code, want := range tests {
+		if got := excel_date_format(164, code); got != want {
+			t.Fatalf("Format '%s' want %t, got %t", code, want, got)
+		}
+	}
+}
+
+//	Build XLSX archive with sheets in name order
+func test_xlsx(sheets map[string]string) []byte {
+	var (
+		names	[]string
+		rels	[]string
+	)
+	files := map[string]string{
+		"xl/styles.xml":		test_xlsx_styles,
+		"xl/sharedStrings.xml":	test_xlsx_strings,
+	}
+	i := 0
+	for _, name := range sorted_keys(sheets) {
+		i++
+		names = append(names, fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, name, i, i))
+		rels = append(rels, fmt.Sprintf(`<Relationship Id="rId%d" Target="worksheets/sheet%d.xml"/>`, i, i))
+		files[fmt.Sprintf("xl/worksheets/sheet%d.xml", i)] = fmt.Sprintf(test_xlsx_sheet, sheets[name])
+	}
+	files["xl/workbook.xml"]			= fmt.Sprintf(test_xlsx_workbook, strings.Join(names, ""))
+	files["xl/_rels/workbook.xml.rels"]	= fmt.Sprintf(test_xlsx_rels, strings.Join(rels, ""))
+	return test_zip(files)
+}
+
+func test_zip(files map[string]string) []byte {
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	for _, name := range sorted_keys(files) {
+		w, _ := z.Create(name)
+		w.Write([]byte(files[name]))
+	}
+	z.Close()
+	return buf.Bytes()
+}
+
+func sorted_keys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func verify_table(t *testing.T, out Table, header, rows string){
+	t.Helper()
+	if got := strings.Join(out.Header, ","); got != header {
+		t.Fatalf("Want: %s\n\nGot: %s", header, got)
+	}
+	s := make([]string, len(out.Rows))
+	for i, line := range out.Rows {
+		s[i] = strings.Join(line.Row, ",")
+	}
+	if got := strings.Join(s, "\n"); got != rows {
+		t.Fatalf("Want: %s\n\nGot: %s", rows, got)
+	}
+}
+
+//	Build BIFF8 workbook in an OLE2 container with one sheet
+func test_xls(cells []byte, sst []string) []byte {
+	le := binary.LittleEndian
+	
+	xf := func(format int) []byte {
+		b := make([]byte, 20)
+		le.PutUint16(b[2:], uint16(format))
+		return test_biff(biff_xf, b)
+	}
+	
+	//	Last string is split by CONTINUE with a switch to 2 byte chars
+	sst_data := le.AppendUint32(nil, uint32(len(sst)))
+	sst_data = le.AppendUint32(sst_data, uint32(len(sst)))
+	for _, s := range sst[:len(sst)-1] {
+		sst_data = append(sst_data, test_xls_string(s, 2)...)
+	}
+	last := []rune(sst[len(sst)-1])
+	sst_data = le.AppendUint16(sst_data, uint16(len(last)))
+	sst_data = append(sst_data, 0, byte(last[0]))
+	cont := []byte{1}
+	for _, c := range utf16.Encode(last[1:]) {
+		cont = le.AppendUint16(cont, c)
+	}
+	
+	globals := test_biff(biff_bof, []byte{0x00, 0x06, 0x05, 0x00})
+	globals = append(globals, test_biff(biff_format, append([]byte{164, 0}, test_xls_string("dd/mm/yyyy", 2)...))...)
+	globals = append(globals, xf(0)...)
+	globals = append(globals, xf(14)...)
+	globals = append(globals, xf(164)...)
+	globals = append(globals, xf(20)...)
+	boundsheet := len(globals)
+	globals = append(globals, test_biff(biff_boundsheet, append(make([]byte, 6), test_xls_string("Data", 1)...))...)
+	globals = append(globals, test_biff(biff_sst, sst_data)...)
+	globals = append(globals, test_biff(biff_continue, cont)...)
+	globals = append(globals, test_biff(biff_eof, nil)...)
+	
+	le.PutUint32(globals[boundsheet+4:], uint32(len(globals)))
+	stream := append(globals, test_biff(biff_bof, []byte{0x00, 0x06, 0x10, 0x00})...)
+	stream = append(stream, cells...)
+	stream = append(stream, test_biff(biff_eof, nil)...)
+	
+	//	Pad to avoid the mini stream
+	for len(stream) < 4096 || len(stream) % 512 != 0 {
+		stream = append(stream, 0)
+	}
+	
+	//	Sector 0: FAT, sector 1: directory, sector 2+: workbook stream
+	sectors := len(stream) / 512
+	header := make([]byte, 512)
+	copy(header, ole2_signature)
+	le.PutUint16(header[0x18:], 0x3E)
+	le.PutUint16(header[0x1A:], 3)
+	le.PutUint16(header[0x1C:], 0xFFFE)
+	le.PutUint16(header[0x1E:], 9)
+	le.PutUint16(header[0x20:], 6)
+	le.PutUint32(header[0x2C:], 1)
+	le.PutUint32(header[0x30:], 1)
+	le.PutUint32(header[0x38:], 4096)
+	le.PutUint32(header[0x3C:], ole2_end_of_chain)
+	le.PutUint32(header[0x44:], ole2_end_of_chain)
+	for i := range ole2_header_difat {
+		le.PutUint32(header[0x4C + i*4:], ole2_free)
+	}
+	le.PutUint32(header[0x4C:], 0)
+	
+	fat := make([]byte, 512)
+	for i := range 128 {
+		le.PutUint32(fat[i*4:], ole2_free)
+	}
+	le.PutUint32(fat, 0xFFFFFFFD)
+	le.PutUint32(fat[4:], ole2_end_of_chain)
+	for i := range sectors {
+		next := uint32(i + 3)
+		if i == sectors - 1 {
+			next = ole2_end_of_chain
+		}
+		le.PutUint32(fat[(i+2)*4:], next)
+	}
+	
+	dir := make([]byte, 512)
+	entry := func(p int, name string, kind byte, start uint32, size int){
+		u := utf16.Encode([]rune(name))
+		for i, c := range u {
+			le.PutUint16(dir[p + i*2:], c)
+		}
+		le.PutUint16(dir[p+0x40:], uint16(len(u) * 2 + 2))
+		dir[p+0x42] = kind
+		le.PutUint32(dir[p+0x74:], start)
+		le.PutUint32(dir[p+0x78:], uint32(size))
+	}
+	entry(0, "Root Entry", 5, ole2_end_of_chain, 0)
+	entry(128, "Workbook", 2, 2, len(stream))
+	
+	out := append(header, fat...)
+	out = append(out, dir...)
+	return append(out, stream...)
+}
+
+func test_xls_string(s string, len_size int) []byte {
+	var b []byte
+	if len_size == 1 {
+		b = []byte{byte(len(s))}
+	} else {
+		b = binary.LittleEndian.AppendUint16(nil, uint16(len(s)))
+	}
+	return append(append(b, 0), s...)
+}
+
+func test_biff(id uint16, data []byte) []byte {
+	b := binary.LittleEndian.AppendUint16(nil, id)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
+
+//	Build ODS archive with tables in name order
+func test_ods(tables map[string]string) []byte {
+	var content strings.Builder
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="`+ods_ns_office+`" xmlns:table="`+ods_ns_table+`" xmlns:text="`+ods_ns_text+`"><office:body><office:spreadsheet>`)
+	for _, name := range sorted_keys(tables) {
+		fmt.Fprintf(&content, `<table:table table:name="%s">%s</table:table>`, name, tables[name])
+	}
+	content.WriteString(`</office:spreadsheet></office:body></office:document-content>`)
+	return test_zip(map[string]string{
+		"mimetype":		MIME_ODS,
+		"content.xml":	content.String(),
+	})
+}
\ No newline at end of file
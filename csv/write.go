@@ -0,0 +1,274 @@
+package csv
+
+import (
+	"io"
+	"os"
+	"fmt"
+	"iter"
+	"bytes"
+	"bufio"
+	"slices"
+	"strings"
+	"github.com/clarkk/go-util/futil"
+)
+
+const (
+	opt_bom			= "bom"
+	opt_quote_all	= "quote_all"
+	opt_round_trip	= "round_trip"
+)
+
+type Writer struct {
+	options			map[string]bool
+	separator		rune
+	line_ending		string
+	charset			string
+}
+
+//	New CSV writer (comma separated, CRLF line endings and UTF8 by default)
+func NewWriter() *Writer {
+	return &Writer{
+		options: map[string]bool{
+			opt_bom:		false,
+			opt_quote_all:	false,
+			opt_round_trip:	false,
+		},
+		separator:		',',
+		line_ending:	LINE_ENDING_CRLF,
+		charset:		charset_utf8,
+	}
+}
+
+//	Separator between cells
+func (w *Writer) Separator(sep rune) *Writer {
+	w.separator = sep
+	return w
+}
+
+//	Line ending (LINE_ENDING_LF, LINE_ENDING_CRLF or LINE_ENDING_CR)
+func (w *Writer) Line_ending(line_ending string) *Writer {
+	w.line_ending = line_ending
+	return w
+}
+
+//	Output charset (e.g. "utf-8", "windows-1252")
+func (w *Writer) Charset(name string) *Writer {
+	w.charset = name
+	return w
+}
+
+//	Write UTF8 BOM so Excel detects the encoding
+func (w *Writer) Bom() *Writer {
+	w.options[opt_bom] = true
+	return w
+}
+
+//	Quote all cells instead of only cells with separators, quotes or line breaks
+func (w *Writer) Quote_all() *Writer {
+	w.options[opt_quote_all] = true
+	return w
+}
+
+//	Verify the output is read back by Reader to the identical table (UTF8 with BOM)
+func (w *Writer) Round_trip() *Writer {
+	w.options[opt_round_trip] = true
+	return w
+}
+
+//	Write table to file
+func (w *Writer) File(file string, t Table) error {
+	var buf bytes.Buffer
+	if err := w.Write(&buf, t); err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, buf.Bytes(), futil.CHMOD_RW_OWNER); err != nil {
+		return new_error(ErrWrite, "Unable to write file", err)
+	}
+	return nil
+}
+
+//	Write table
+func (w *Writer) Write(dst io.Writer, t Table) error {
+	return w.Write_rows(dst, t.Header, func(yield func(Row, error) bool){
+		for _, row := range t.Rows {
+			if !yield(row, nil) {
+				return
+			}
+		}
+	})
+}
+
+//	Write header (if any) and rows
+func (w *Writer) Write_rows(dst io.Writer, header Header, rows iter.Seq2[Row, error]) error {
+	if err := w.check_options(); err != nil {
+		return err
+	}
+	
+	var (
+		buf		bytes.Buffer
+		out		= bufio.NewWriter(dst)
+		written	Table
+	)
+	//	Output is held back until it is verified
+	if w.options[opt_round_trip] {
+		out			= bufio.NewWriter(&buf)
+		written		= Table{Header: header}
+	}
+	
+	if w.options[opt_bom] {
+		out.WriteString(BOM_UTF8)
+	}
+	if len(header) != 0 {
+		if err := w.write_line(out, header); err != nil {
+			return err
+		}
+	}
+	for row, err := range rows {
+		if err != nil {
+			return err
+		}
+		if err := w.write_line(out, row.Row); err != nil {
+			return err
+		}
+		if w.options[opt_round_trip] {
+			written.Rows = append(written.Rows, row)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return new_error(ErrWrite, "Unable to write CSV", err)
+	}
+	
+	if w.options[opt_round_trip] {
+		if err := round_trip(buf.Bytes(), written); err != nil {
+			return err
+		}
+		if _, err := dst.Write(buf.Bytes()); err != nil {
+			return new_error(ErrWrite, "Unable to write CSV", err)
+		}
+	}
+	return nil
+}
+
+func (w *Writer) check_options() error {
+	if !valid_separator(w.separator) {
+		return new_error(ErrOptions, fmt.Sprintf("Invalid separator: %q", w.separator), nil)
+	}
+	
+	switch w.line_ending {
+	case LINE_ENDING_LF, LINE_ENDING_CRLF, LINE_ENDING_CR:
+	default:
+		return new_error(ErrOptions, "Invalid line ending: "+w.line_ending, nil)
+	}
+	
+	charset, ok := charset_names[strings.ToLower(w.charset)]
+	if !ok || charset == charset_utf16le || charset == charset_utf16be {
+		return new_error(ErrOptions, "Unsupported charset: "+w.charset, nil)
+	}
+	w.charset = charset
+	
+	if w.options[opt_bom] && w.charset != charset_utf8 {
+		return new_error(ErrOptions, "BOM can only be written with UTF8", nil)
+	}
+	
+	//	Reader only detects UTF8 reliably with BOM and the default separators
+	if w.options[opt_round_trip] {
+		if w.charset != charset_utf8 {
+			return new_error(ErrOptions, "Option 'round_trip' can only be used with UTF8", nil)
+		}
+		if !slices.Contains(separators, w.separator) {
+			return new_error(ErrOptions, fmt.Sprintf("Option 'round_trip' can not be used with separator: %q", w.separator), nil)
+		}
+		w.options[opt_bom] = true
+	}
+	return nil
+}
+
+func (w *Writer) write_line(out *bufio.Writer, line []string) error {
+	var b strings.Builder
+	for i, value := range line {
+		if i != 0 {
+			b.WriteRune(w.separator)
+		}
+		if w.options[opt_quote_all] || w.needs_quotes(value) {
+			b.WriteByte('"')
+			b.WriteString(strings.ReplaceAll(value, `"`, `""`))
+			b.WriteByte('"')
+		} else {
+			b.WriteString(value)
+		}
+	}
+	b.WriteString(line_break(w.line_ending))
+	
+	if w.charset == charset_utf8 {
+		_, err := out.WriteString(b.String())
+		return err
+	}
+	encoded, err := encode_charset(b.String(), w.charset)
+	if err != nil {
+		return new_error(ErrWrite, "Unable to encode CSV", err)
+	}
+	_, err = out.Write(encoded)
+	return err
+}
+
+func (w *Writer) needs_quotes(value string) bool {
+	if value == "" {
+		return false
+	}
+	return strings.ContainsRune(value, w.separator) ||
+		strings.ContainsAny(value, "\"\r\n") ||
+		value[0] == ' ' || value[0] == '\t' ||
+		value[len(value)-1] == ' ' || value[len(value)-1] == '\t'
+}
+
+func line_break(line_ending string) string {
+	switch line_ending {
+	case LINE_ENDING_LF:
+		return "\n"
+	case LINE_ENDING_CR:
+		return "\r"
+	}
+	return "\r\n"
+}
+
+//	Read output back and compare with the written table
+func round_trip(b []byte, t Table) error {
+	r := NewReader("")
+	if len(t.Header) == 0 {
+		r.Ignore_header()
+	}
+	out, err := r.Bytes(b, "")
+	if err != nil {
+		return new_error(ErrRoundTrip, "", err)
+	}
+	
+	if !slices.Equal(out.Header, t.Header) {
+		return new_error(ErrRoundTrip, "Round trip failed: header differs", nil)
+	}
+	//	Empty rows are skipped by the reader
+	var rows Rows
+	for _, row := range t.Rows {
+		if trim_line(slices.Clone(row.Row)) {
+			rows = append(rows, row)
+		}
+	}
+	if len(out.Rows) != len(rows) {
+		return new_error(ErrRoundTrip, fmt.Sprintf("Round trip failed: %d rows written, %d read", len(rows), len(out.Rows)), nil)
+	}
+	for i, row := range rows {
+		got := out.Rows[i].Row
+		for c := range max(len(got), len(row.Row)) {
+			var want, value string
+			if c < len(row.Row) {
+				want = row.Row[c]
+			}
+			if c < len(got) {
+				value = got[c]
+			}
+			if value != want {
+				return new_error(ErrRoundTrip, fmt.Sprintf("Round trip failed: row %d column %d differs", i, c), nil).at(i + 1, c + 1)
+			}
+		}
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,87 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_write(t *testing.T){
+	table := Table{
+		Header:	Header{"Name", "Note", "Amount"},
+		Rows:	Rows{
+			{Line: 1, Row: []string{"Æble", `Say "hi"`, "1,5"}},
+			{Line: 2, Row: []string{"Pære", "Two\nlines", "€ 12"}},
+			{Line: 3, Row: []string{"Blomme", " padded", ""}},
+		},
+	}
+	
+	var buf bytes.Buffer
+	if err := NewWriter().Write(&buf, table); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := "Name,Note,Amount\r\nÆble,\"Say \"\"hi\"\"\",\"1,5\"\r\nPære,\"Two\nlines\",€ 12\r\nBlomme,\" padded\",\r\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("Want: %q\n\nGot: %q", want, got)
+	}
+	
+	t.Run("windows-1252", func(t *testing.T){
+		var buf bytes.Buffer
+		err := NewWriter().Separator(';').Line_ending(LINE_ENDING_CR).Charset("windows-1252").Write(&buf, table)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("\x80 12")) {
+			t.Fatalf("Want euro sign as 0x80, got %q", buf.String())
+		}
+		
+		out, err := NewReader("").Charset("windows-1252").Bytes(buf.Bytes(), "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if got := out.Get(1, "Amount"); got != "€ 12" {
+			t.Fatalf("Want € 12, got %s", got)
+		}
+		
+		bad := Table{Header: Header{"a", "b"}, Rows: Rows{{Line: 1, Row: []string{"Ł", "x"}}}}
+		if err := NewWriter().Charset("windows-1252").Write(&buf, bad); err == nil {
+			t.Fatal("Expected an error")
+		}
+	})
+	
+	t.Run("round trip", func(t *testing.T){
+		trip := Table{
+			Header:	Header{"Name", "Note", "Amount"},
+			Rows:	Rows{
+				{Line: 1, Row: []string{"Æble", `Say "hi"`, "1,5"}},
+				{Line: 2, Row: []string{"Pære", "Two\nlines", "€ 12"}},
+				{Line: 3, Row: []string{"Blomme", "x;y", ""}},
+			},
+		}
+		var buf bytes.Buffer
+		if err := NewWriter().Separator(';').Round_trip().Write(&buf, trip); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !strings.HasPrefix(buf.String(), BOM_UTF8) {
+			t.Fatal("Want UTF8 BOM")
+		}
+		
+		out, err := NewReader("").Bytes(buf.Bytes(), "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		for i, row := range trip.Rows {
+			if got, want := strings.Join(out.Rows[i].Row, "|"), strings.Join(row.Row, "|"); got != want {
+				t.Fatalf("Row %d want: %s\n\nGot: %s", i, want, got)
+			}
+		}
+		
+		buf.Reset()
+		if err := NewWriter().Round_trip().Write(&buf, table); err == nil || buf.Len() != 0 {
+			t.Fatalf("Expected an error and no output, got %v", err)
+		}
+		if err := NewWriter().Separator('|').Round_trip().Write(&buf, trip); err == nil {
+			t.Fatal("Expected an error")
+		}
+	})
+}
\ No newline at end of file
@@ -0,0 +1,696 @@
+package csv
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"encoding/binary"
+)
+
+const (
+	ole2_signature		= "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1"
+	ole2_end_of_chain	= 0xFFFFFFFE
+	ole2_free			= 0xFFFFFFFF
+	ole2_header_difat	= 109
+	
+	biff_bof			= 0x0809
+	biff_eof			= 0x000A
+	biff_filepass		= 0x002F
+	biff_datemode		= 0x0022
+	biff_boundsheet		= 0x0085
+	biff_format			= 0x041E
+	biff_xf				= 0x00E0
+	biff_sst			= 0x00FC
+	biff_continue		= 0x003C
+	biff_labelsst		= 0x00FD
+	biff_label			= 0x0204
+	biff_rstring		= 0x00D6
+	biff_number			= 0x0203
+	biff_rk				= 0x027E
+	biff_mulrk			= 0x00BD
+	biff_boolerr		= 0x0205
+	biff_formula		= 0x0006
+	biff_string			= 0x0207
+	
+	biff8				= 0x0600
+)
+
+type (
+	xls struct {
+		lim			*limits
+		stream		[]byte
+		sheets		[]xls_sheet
+		strings		[]string
+		date_styles	[]bool
+		date1904	bool
+	}
+	
+	xls_sheet struct {
+		name	string
+		offset	int
+	}
+	
+	ole2 struct {
+		lim				*limits
+		b				[]byte
+		sector_size		int
+		mini_size		int
+		mini_cutoff		int
+		fat				[]uint32
+		mini_fat		[]uint32
+		mini_stream		[]byte
+	}
+	
+	biff_record struct {
+		id		uint16
+		data	[]byte
+	}
+	
+	//	Record data split by CONTINUE records
+	biff_segments struct {
+		segs	[][]byte
+		i		int
+		pos		int
+	}
+)
+
+func open_xls(b []byte, lim *limits) (*xls, error){
+	doc, err := open_ole2(b, lim)
+	if err != nil {
+		return nil, err
+	}
+	
+	x := &xls{
+		lim:	lim,
+	}
+	if x.stream, err = doc.stream("Workbook"); err != nil {
+		//	BIFF5 and older use "Book"
+		if _, err := doc.stream("Book"); err == nil {
+			return nil, fmt.Errorf("Only BIFF8 XLS files are supported")
+		}
+		return nil, err
+	}
+	
+	if err := x.read_globals(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *xls) sheet_names() []string {
+	names := make([]string, len(x.sheets))
+	for i, sheet := range x.sheets {
+		names[i] = sheet.name
+	}
+	return names
+}
+
+func (x *xls) rows(i int) ([][]string, error){
+	var (
+		lines		[][]string
+		depth		int
+		formula		[2]int
+		pending		bool
+		limit_err	error
+	)
+	set := func(row, col int, value string){
+		if limit_err != nil {
+			return
+		}
+		if limit_err = x.lim.sheet_cell(row, col, value); limit_err != nil {
+			return
+		}
+		for len(lines) <= row {
+			lines = append(lines, nil)
+		}
+		for len(lines[row]) <= col {
+			lines[row] = append(lines[row], "")
+		}
+		lines[row][col] = value
+	}
+	
+	for rec, err := range x.records(x.sheets[i].offset) {
+		if err != nil {
+			return nil, err
+		}
+		
+		d := rec.data
+		var row, col int
+		if len(d) >= 4 {
+			row, col = xls_cell(d)
+		}
+		
+		switch rec.id {
+		//	Embedded charts have their own BOF/EOF substream
+		case biff_bof:
+			depth++
+		case biff_eof:
+			depth--
+			if depth <= 0 {
+				return lines, nil
+			}
+		case biff_labelsst:
+			if len(d) >= 10 {
+				if s := int(binary.LittleEndian.Uint32(d[6:])); s < len(x.strings) {
+					set(row, col, x.strings[s])
+				}
+			}
+		case biff_label, biff_rstring:
+			if len(d) >= 9 {
+				s, _ := xls_string(d[6:], 2)
+				set(row, col, s)
+			}
+		case biff_number:
+			if len(d) >= 14 {
+				f := math.Float64frombits(binary.LittleEndian.Uint64(d[6:]))
+				set(row, col, x.number(f, xls_style(d)))
+			}
+		case biff_rk:
+			if len(d) >= 10 {
+				set(row, col, x.number(xls_rk(binary.LittleEndian.Uint32(d[6:])), xls_style(d)))
+			}
+		case biff_mulrk:
+			//	Cells are (style, rk) pairs followed by the last column index
+			for p := 4; p + 6 <= len(d) - 2; p += 6 {
+				style := int(binary.LittleEndian.Uint16(d[p:]))
+				set(row, col, x.number(xls_rk(binary.LittleEndian.Uint32(d[p+2:])), style))
+				col++
+			}
+		case biff_boolerr:
+			if len(d) >= 8 {
+				set(row, col, xls_boolerr(d[6], d[7]))
+			}
+		case biff_formula:
+			if len(d) < 14 {
+				continue
+			}
+			result := d[6:14]
+			if binary.LittleEndian.Uint16(result[6:]) != 0xFFFF {
+				set(row, col, x.number(math.Float64frombits(binary.LittleEndian.Uint64(result)), xls_style(d)))
+				continue
+			}
+			switch result[0] {
+			//	String result follows in STRING record
+			case 0:
+				formula	= [2]int{row, col}
+				pending	= true
+			case 1:
+				set(row, col, xls_boolerr(result[2], 0))
+			case 2:
+				set(row, col, xls_boolerr(result[2], 1))
+			}
+		case biff_string:
+			if pending {
+				s, _ := xls_string(d, 2)
+				set(formula[0], formula[1], s)
+				pending = false
+			}
+		}
+		if limit_err != nil {
+			return nil, limit_err
+		}
+	}
+	return lines, nil
+}
+
+func (x *xls) number(f float64, style int) string {
+	if style < len(x.date_styles) && x.date_styles[style] {
+		return excel_date(f, x.date1904)
+	}
+	return excel_number(f)
+}
+
+func (x *xls) read_globals() error {
+	var (
+		formats	= map[int]string{}
+		xfs		[]int
+		sst		*biff_segments
+		sst_open	bool
+	)
+	first := true
+	for rec, err := range x.records(0) {
+		if err != nil {
+			return err
+		}
+		
+		if first {
+			if rec.id != biff_bof || len(rec.data) < 2 {
+				return fmt.Errorf("XLS BOF record missing")
+			}
+			if binary.LittleEndian.Uint16(rec.data) != biff8 {
+				return fmt.Errorf("Only BIFF8 XLS files are supported")
+			}
+			first = false
+			continue
+		}
+		
+		d := rec.data
+		switch rec.id {
+		case biff_filepass:
+			return fmt.Errorf("XLS file is encrypted")
+		case biff_datemode:
+			x.date1904 = len(d) >= 2 && binary.LittleEndian.Uint16(d) == 1
+		case biff_boundsheet:
+			if len(d) < 8 {
+				continue
+			}
+			//	Only worksheets
+			if d[5] != 0 {
+				continue
+			}
+			name, _ := xls_string(d[6:], 1)
+			x.sheets = append(x.sheets, xls_sheet{
+				name,
+				int(binary.LittleEndian.Uint32(d)),
+			})
+		case biff_format:
+			if len(d) >= 2 {
+				formats[int(binary.LittleEndian.Uint16(d))], _ = xls_string(d[2:], 2)
+			}
+		case biff_xf:
+			if len(d) >= 4 {
+				xfs = append(xfs, int(binary.LittleEndian.Uint16(d[2:])))
+			}
+		case biff_sst:
+			sst			= &biff_segments{segs: [][]byte{d}}
+			sst_open	= true
+			continue
+		//	CONTINUE records only extend the SST directly before them
+		case biff_continue:
+			if sst_open {
+				sst.segs = append(sst.segs, d)
+			}
+			continue
+		case biff_eof:
+			x.date_styles = make([]bool, len(xfs))
+			for i, id := range xfs {
+				x.date_styles[i] = excel_date_format(id, formats[id])
+			}
+			if sst != nil {
+				var err error
+				if x.strings, err = sst.strings(x.lim); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		sst_open = false
+	}
+	return fmt.Errorf("XLS globals EOF record missing")
+}
+
+//	Iterate BIFF records from stream offset (conversion deadline and context are checked)
+func (x *xls) records(offset int) iter.Seq2[biff_record, error] {
+	return func(yield func(biff_record, error) bool){
+		for p, n := offset, 0; p + 4 <= len(x.stream); n++ {
+			if err := x.lim.converting_at(n); err != nil {
+				yield(biff_record{}, err)
+				return
+			}
+			id		:= binary.LittleEndian.Uint16(x.stream[p:])
+			size	:= int(binary.LittleEndian.Uint16(x.stream[p+2:]))
+			p += 4
+			if p + size > len(x.stream) {
+				yield(biff_record{}, fmt.Errorf("XLS record truncated"))
+				return
+			}
+			if !yield(biff_record{id, x.stream[p:p+size]}, nil) {
+				return
+			}
+			p += size
+		}
+	}
+}
+
+//	Parse shared string table
+func (s *biff_segments) strings(lim *limits) ([]string, error){
+	head, ok := s.read(8)
+	if !ok {
+		return nil, nil
+	}
+	unique := int(binary.LittleEndian.Uint32(head[4:]))
+	
+	list := make([]string, 0, min(unique, 65536))
+	for i := range unique {
+		if err := lim.converting_at(i); err != nil {
+			return nil, err
+		}
+		h, ok := s.read(3)
+		if !ok {
+			break
+		}
+		var (
+			count	= int(binary.LittleEndian.Uint16(h))
+			flags	= h[2]
+			runs	int
+			ext		int
+		)
+		if flags & 0x08 != 0 {
+			b, ok := s.read(2)
+			if !ok {
+				break
+			}
+			runs = int(binary.LittleEndian.Uint16(b))
+		}
+		if flags & 0x04 != 0 {
+			b, ok := s.read(4)
+			if !ok {
+				break
+			}
+			ext = int(binary.LittleEndian.Uint32(b))
+		}
+		str, ok := s.chars(count, flags & 0x01 != 0)
+		if !ok {
+			break
+		}
+		list = append(list, str)
+		if _, ok := s.read(runs * 4 + ext); !ok {
+			break
+		}
+	}
+	return list, nil
+}
+
+//	Read bytes across segments
+func (s *biff_segments) read(n int) ([]byte, bool){
+	var out []byte
+	for n > 0 {
+		if s.i >= len(s.segs) {
+			return nil, false
+		}
+		seg := s.segs[s.i][s.pos:]
+		if len(seg) == 0 {
+			s.i++
+			s.pos = 0
+			continue
+		}
+		c := min(n, len(seg))
+		out = append(out, seg[:c]...)
+		s.pos += c
+		n -= c
+	}
+	return out, true
+}
+
+//	Read characters (each CONTINUE record starts with a new high byte flag)
+func (s *biff_segments) chars(count int, high bool) (string, bool){
+	var u []uint16
+	for len(u) < count {
+		if s.i >= len(s.segs) {
+			return "", false
+		}
+		seg := s.segs[s.i][s.pos:]
+		if len(seg) == 0 {
+			s.i++
+			s.pos = 0
+			if s.i >= len(s.segs) || len(s.segs[s.i]) == 0 {
+				return "", false
+			}
+			high	= s.segs[s.i][0] & 0x01 != 0
+			s.pos	= 1
+			continue
+		}
+		if high {
+			for len(seg) >= 2 && len(u) < count {
+				u = append(u, binary.LittleEndian.Uint16(seg))
+				seg = seg[2:]
+				s.pos += 2
+			}
+			if len(seg) == 1 {
+				return "", false
+			}
+		} else {
+			for len(seg) >= 1 && len(u) < count {
+				u = append(u, uint16(seg[0]))
+				seg = seg[1:]
+				s.pos++
+			}
+		}
+	}
+	return string(utf16.Decode(u)), true
+}
+
+func open_ole2(b []byte, lim *limits) (*ole2, error){
+	if len(b) < 512 || !bytes.HasPrefix(b, []byte(ole2_signature)) {
+		return nil, fmt.Errorf("Invalid OLE2 signature")
+	}
+	
+	le := binary.LittleEndian
+	shift		:= le.Uint16(b[0x1E:])
+	mini_shift	:= le.Uint16(b[0x20:])
+	if shift != 9 && shift != 12 || mini_shift != 6 {
+		return nil, fmt.Errorf("Invalid OLE2 sector size")
+	}
+	
+	doc := &ole2{
+		lim:			lim,
+		b:				b,
+		sector_size:	1 << shift,
+		mini_size:		1 << mini_shift,
+		mini_cutoff:	int(le.Uint32(b[0x38:])),
+	}
+	
+	//	Collect FAT sector locations from header and DIFAT chain
+	var fat_sectors []uint32
+	for i := range ole2_header_difat {
+		if s := le.Uint32(b[0x4C + i*4:]); s != ole2_free {
+			fat_sectors = append(fat_sectors, s)
+		}
+	}
+	difat := le.Uint32(b[0x44:])
+	for n := 0; difat != ole2_end_of_chain && difat != ole2_free; n++ {
+		if err := lim.converting_at(n); err != nil {
+			return nil, err
+		}
+		sector, err := doc.sector(difat)
+		if err != nil || n > len(b) / doc.sector_size {
+			return nil, fmt.Errorf("Invalid OLE2 DIFAT")
+		}
+		last := doc.sector_size / 4 - 1
+		for i := range last {
+			if s := le.Uint32(sector[i*4:]); s != ole2_free {
+				fat_sectors = append(fat_sectors, s)
+			}
+		}
+		difat = le.Uint32(sector[last*4:])
+	}
+	
+	for n, s := range fat_sectors {
+		if err := lim.converting_at(n); err != nil {
+			return nil, err
+		}
+		sector, err := doc.sector(s)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid OLE2 FAT: %w", err)
+		}
+		for i := 0; i < len(sector); i += 4 {
+			doc.fat = append(doc.fat, le.Uint32(sector[i:]))
+		}
+	}
+	
+	if mini_fat := le.Uint32(b[0x3C:]); mini_fat != ole2_end_of_chain {
+		data, err := doc.chain(mini_fat, -1)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid OLE2 mini FAT: %w", err)
+		}
+		for i := 0; i + 4 <= len(data); i += 4 {
+			doc.mini_fat = append(doc.mini_fat, le.Uint32(data[i:]))
+		}
+	}
+	return doc, nil
+}
+
+//	Read stream by name from root storage
+func (doc *ole2) stream(name string) ([]byte, error){
+	dir, err := doc.chain(binary.LittleEndian.Uint32(doc.b[0x30:]), -1)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid OLE2 directory: %w", err)
+	}
+	
+	le := binary.LittleEndian
+	for p := 0; p + 128 <= len(dir); p += 128 {
+		entry		:= dir[p:p+128]
+		name_len	:= int(le.Uint16(entry[0x40:]))
+		kind		:= entry[0x42]
+		start		:= le.Uint32(entry[0x74:])
+		size		:= int(le.Uint32(entry[0x78:]))
+		
+		//	Root entry holds the mini stream
+		if kind == 5 && doc.mini_stream == nil {
+			if doc.mini_stream, err = doc.chain(start, size); err != nil {
+				return nil, fmt.Errorf("Invalid OLE2 mini stream: %w", err)
+			}
+			continue
+		}
+		if kind != 2 || name_len < 2 || name_len > 64 {
+			continue
+		}
+		
+		u := make([]uint16, name_len/2 - 1)
+		for i := range u {
+			u[i] = le.Uint16(entry[i*2:])
+		}
+		if !strings.EqualFold(string(utf16.Decode(u)), name) {
+			continue
+		}
+		
+		if size < doc.mini_cutoff {
+			return doc.mini_chain(start, size)
+		}
+		return doc.chain(start, size)
+	}
+	return nil, fmt.Errorf("OLE2 stream not found: %s", name)
+}
+
+//	Read sector chain from FAT (size -1 reads the whole chain)
+func (doc *ole2) chain(start uint32, size int) ([]byte, error){
+	var out []byte
+	for s, n := start, 0; s != ole2_end_of_chain; n++ {
+		if n > len(doc.b) / doc.sector_size {
+			return nil, fmt.Errorf("OLE2 sector chain loop")
+		}
+		if err := doc.lim.converting_at(n); err != nil {
+			return nil, err
+		}
+		sector, err := doc.sector(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sector...)
+		if size >= 0 && len(out) >= size {
+			return out[:size], nil
+		}
+		if int(s) >= len(doc.fat) {
+			return nil, fmt.Errorf("OLE2 sector out of FAT range: %d", s)
+		}
+		s = doc.fat[s]
+	}
+	if size > len(out) {
+		return nil, fmt.Errorf("OLE2 stream truncated")
+	}
+	return out, nil
+}
+
+//	Read sector chain from mini FAT
+func (doc *ole2) mini_chain(start uint32, size int) ([]byte, error){
+	var out []byte
+	for s, n := start, 0; s != ole2_end_of_chain && len(out) < size; n++ {
+		if err := doc.lim.converting_at(n); err != nil {
+			return nil, err
+		}
+		p := int(s) * doc.mini_size
+		if n > len(doc.mini_fat) || int(s) >= len(doc.mini_fat) || p + doc.mini_size > len(doc.mini_stream) {
+			return nil, fmt.Errorf("Invalid OLE2 mini sector: %d", s)
+		}
+		out = append(out, doc.mini_stream[p:p+doc.mini_size]...)
+		s = doc.mini_fat[s]
+	}
+	if len(out) < size {
+		return nil, fmt.Errorf("OLE2 stream truncated")
+	}
+	return out[:size], nil
+}
+
+//	Read sector (the last sector may be truncated)
+func (doc *ole2) sector(s uint32) ([]byte, error){
+	p := (int(s) + 1) * doc.sector_size
+	if s >= 0xFFFFFFFA || p >= len(doc.b) {
+		return nil, fmt.Errorf("OLE2 sector out of range: %d", s)
+	}
+	return doc.b[p:min(p + doc.sector_size, len(doc.b))], nil
+}
+
+//	Decode BIFF8 unicode string with 1 or 2 byte length prefix
+func xls_string(b []byte, len_size int) (string, int){
+	if len(b) < len_size + 1 {
+		return "", 0
+	}
+	var count int
+	if len_size == 1 {
+		count = int(b[0])
+	} else {
+		count = int(binary.LittleEndian.Uint16(b))
+	}
+	flags	:= b[len_size]
+	p		:= len_size + 1
+	if flags & 0x08 != 0 {
+		p += 2
+	}
+	if flags & 0x04 != 0 {
+		p += 4
+	}
+	
+	if flags & 0x01 == 0 {
+		end := min(p + count, len(b))
+		if p > end {
+			return "", 0
+		}
+		u := make([]uint16, end - p)
+		for i, c := range b[p:end] {
+			u[i] = uint16(c)
+		}
+		return string(utf16.Decode(u)), end
+	}
+	
+	end := min(p + count*2, len(b) &^ 1)
+	if p > end {
+		return "", 0
+	}
+	u := make([]uint16, (end - p) / 2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[p + i*2:])
+	}
+	return string(utf16.Decode(u)), end
+}
+
+//	Decode RK number
+func xls_rk(rk uint32) float64 {
+	var f float64
+	if rk & 0x02 != 0 {
+		f = float64(int32(rk) >> 2)
+	} else {
+		f = math.Float64frombits(uint64(rk &^ 0x03) << 32)
+	}
+	if rk & 0x01 != 0 {
+		f /= 100
+	}
+	return f
+}
+
+func xls_boolerr(value byte, is_error byte) string {
+	if is_error == 0 {
+		if value != 0 {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	switch value {
+	case 0x00:
+		return "#NULL!"
+	case 0x07:
+		return "#DIV/0!"
+	case 0x0F:
+		return "#VALUE!"
+	case 0x17:
+		return "#REF!"
+	case 0x1D:
+		return "#NAME?"
+	case 0x24:
+		return "#NUM!"
+	case 0x2A:
+		return "#N/A"
+	}
+	return "#ERR"+strconv.Itoa(int(value))
+}
+
+func xls_cell(d []byte) (int, int){
+	return int(binary.LittleEndian.Uint16(d)), int(binary.LittleEndian.Uint16(d[2:]))
+}
+
+func xls_style(d []byte) int {
+	return int(binary.LittleEndian.Uint16(d[4:]))
+}
\ No newline at end of file
@@ -0,0 +1,371 @@
+package csv
+
+import (
+	"io"
+	"fmt"
+	"path"
+	"bytes"
+	"strconv"
+	"strings"
+	"archive/zip"
+	"encoding/xml"
+)
+
+type (
+	xlsx struct {
+		lim			*limits
+		files		map[string]*zip.File
+		sheets		[]xlsx_sheet
+		strings		[]string
+		date_styles	[]bool
+		date1904	bool
+	}
+	
+	xlsx_sheet struct {
+		name	string
+		file	string
+	}
+	
+	xlsx_attrs struct {
+		Attrs	[]xml.Attr	`xml:",any,attr"`
+	}
+	
+	xlsx_workbook struct {
+		Pr		struct {
+			Date1904	string	`xml:"date1904,attr"`
+		}	`xml:"workbookPr"`
+		Sheets	[]xlsx_attrs	`xml:"sheets>sheet"`
+	}
+	
+	xlsx_rels struct {
+		Rels	[]struct {
+			Id		string	`xml:"Id,attr"`
+			Target	string	`xml:"Target,attr"`
+		}	`xml:"Relationship"`
+	}
+	
+	xlsx_styles struct {
+		Formats	[]struct {
+			Id		int		`xml:"numFmtId,attr"`
+			Code	string	`xml:"formatCode,attr"`
+		}	`xml:"numFmts>numFmt"`
+		Xfs		[]struct {
+			Format	int		`xml:"numFmtId,attr"`
+		}	`xml:"cellXfs>xf"`
+	}
+)
+
+func open_xlsx(b []byte, lim *limits) (*xlsx, error){
+	z, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid XLSX archive: %w", err)
+	}
+	
+	x := &xlsx{
+		lim:	lim,
+		files:	map[string]*zip.File{},
+	}
+	for _, f := range z.File {
+		x.files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+	
+	if err := x.read_workbook(); err != nil {
+		return nil, err
+	}
+	if err := x.read_strings(); err != nil {
+		return nil, err
+	}
+	if err := x.read_styles(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *xlsx) sheet_names() []string {
+	names := make([]string, len(x.sheets))
+	for i, sheet := range x.sheets {
+		names[i] = sheet.name
+	}
+	return names
+}
+
+func (x *xlsx) rows(i int) ([][]string, error){
+	f, err := x.open(x.sheets[i].file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	
+	var (
+		lines	[][]string
+		line	[]string
+		col		int
+		cell	struct {
+			t		string
+			style	int
+			value	strings.Builder
+			text	bool
+		}
+	)
+	dec := xml.NewDecoder(f)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid XLSX sheet: %w", err)
+		}
+		
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				line	= nil
+				col		= 0
+				if n, err := strconv.Atoi(attr(t, "r")); err == nil {
+					if n > sheet_rows {
+						return nil, fmt.Errorf("Invalid XLSX row reference: %d", n)
+					}
+					if err := x.lim.sheet_cell(n - 1, 0, ""); err != nil {
+						return nil, err
+					}
+					//	Keep skipped rows so the line index matches the sheet row
+					for len(lines) < n - 1 {
+						lines = append(lines, nil)
+					}
+				}
+			case "c":
+				cell.t		= attr(t, "t")
+				cell.style	= -1
+				cell.value.Reset()
+				if s, err := strconv.Atoi(attr(t, "s")); err == nil {
+					cell.style = s
+				}
+				if c := xlsx_col(attr(t, "r")); c >= 0 {
+					col = c
+				}
+				if col >= sheet_cols {
+					return nil, fmt.Errorf("Invalid XLSX cell reference: %s", attr(t, "r"))
+				}
+			case "v", "t":
+				cell.text = true
+			}
+		case xml.CharData:
+			if cell.text {
+				cell.value.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				cell.text = false
+			case "c":
+				value := x.cell_value(cell.t, cell.style, cell.value.String())
+				if err := x.lim.sheet_cell(len(lines), col, value); err != nil {
+					return nil, err
+				}
+				for len(line) < col {
+					line = append(line, "")
+				}
+				line = append(line, value)
+				col++
+			case "row":
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines, nil
+}
+
+func (x *xlsx) cell_value(t string, style int, value string) string {
+	switch t {
+	case "s":
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 || i >= len(x.strings) {
+			return ""
+		}
+		return x.strings[i]
+	case "b":
+		if value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "str", "inlineStr", "e", "d":
+		return value
+	}
+	
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	if style >= 0 && style < len(x.date_styles) && x.date_styles[style] {
+		return excel_date(f, x.date1904)
+	}
+	return excel_number(f)
+}
+
+func (x *xlsx) read_workbook() error {
+	var wb xlsx_workbook
+	if err := x.decode("xl/workbook.xml", &wb); err != nil {
+		return err
+	}
+	x.date1904 = wb.Pr.Date1904 == "1" || wb.Pr.Date1904 == "true"
+	
+	var rels xlsx_rels
+	if err := x.decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return err
+	}
+	targets := map[string]string{}
+	for _, rel := range rels.Rels {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.Id] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.Id] = path.Join("xl", rel.Target)
+		}
+	}
+	
+	for _, sheet := range wb.Sheets {
+		var name, id string
+		for _, a := range sheet.Attrs {
+			switch a.Name.Local {
+			case "name":
+				name = a.Value
+			case "id":
+				id = a.Value
+			}
+		}
+		if file, ok := targets[id]; ok {
+			x.sheets = append(x.sheets, xlsx_sheet{name, file})
+		}
+	}
+	return nil
+}
+
+func (x *xlsx) read_strings() error {
+	if _, ok := x.files["xl/sharedStrings.xml"]; !ok {
+		return nil
+	}
+	f, err := x.open("xl/sharedStrings.xml")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	
+	//	Phonetic runs are not part of the value
+	var (
+		s			strings.Builder
+		text		bool
+		phonetic	bool
+	)
+	dec := xml.NewDecoder(f)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid XLSX shared strings: %w", err)
+		}
+		
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				s.Reset()
+			case "t":
+				text = !phonetic
+			case "rPh":
+				phonetic = true
+			}
+		case xml.CharData:
+			if text {
+				s.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				x.strings = append(x.strings, s.String())
+			case "t":
+				text = false
+			case "rPh":
+				phonetic = false
+			}
+		}
+	}
+}
+
+func (x *xlsx) read_styles() error {
+	if _, ok := x.files["xl/styles.xml"]; !ok {
+		return nil
+	}
+	var styles xlsx_styles
+	if err := x.decode("xl/styles.xml", &styles); err != nil {
+		return err
+	}
+	
+	codes := map[int]string{}
+	for _, format := range styles.Formats {
+		codes[format.Id] = format.Code
+	}
+	x.date_styles = make([]bool, len(styles.Xfs))
+	for i, xf := range styles.Xfs {
+		x.date_styles[i] = excel_date_format(xf.Format, codes[xf.Format])
+	}
+	return nil
+}
+
+func (x *xlsx) decode(name string, v any) error {
+	f, err := x.open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	
+	if err := xml.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("Invalid XLSX file %s: %w", name, err)
+	}
+	return nil
+}
+
+func (x *xlsx) open(name string) (io.ReadCloser, error){
+	f, ok := x.files[name]
+	if !ok {
+		return nil, fmt.Errorf("XLSX file missing: %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return x.lim.read_closer(rc), nil
+}
+
+//	Get column index from cell reference (A1 => 0) and sheet_cols if more than 3 letters
+func xlsx_col(ref string) int {
+	col := 0
+	for i := 0; i < len(ref); i++ {
+		if i == 3 {
+			if c := ref[i] | 0x20; c >= 'a' && c <= 'z' {
+				return sheet_cols
+			}
+			break
+		}
+		c := ref[i]
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col * 26 + int(c - 'A' + 1)
+	}
+	return col - 1
+}
+
+func attr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
\ No newline at end of file
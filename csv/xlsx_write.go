@@ -0,0 +1,334 @@
+package csv
+
+import (
+	"io"
+	"os"
+	"fmt"
+	"math"
+	"time"
+	"bytes"
+	"strings"
+	"strconv"
+	"archive/zip"
+	"encoding/xml"
+	"unicode/utf8"
+	"github.com/clarkk/go-util/futil"
+)
+
+const (
+	xlsx_date_format		= "yyyy-mm-dd"
+	xlsx_datetime_format	= "yyyy-mm-dd hh:mm:ss"
+	xlsx_percent_format		= "0.00%"
+	
+	//	Custom number formats start after the built-in ids
+	xlsx_format_offset		= 164
+	//	Excel keeps 15 significant digits (longer numbers like account numbers are written as text)
+	xlsx_max_digits			= 15
+	xlsx_min_width			= 8
+	xlsx_max_width			= 60
+)
+
+type (
+	Xlsx_writer struct {
+		sheet		string
+		types		[]Column_type
+		formats		[]string
+	}
+	
+	xlsx_style_sheet struct {
+		formats		[]string
+		xfs			map[string]int
+	}
+)
+
+//	New XLSX writer (cells are written as text unless column types are given)
+func NewXlsx_writer() *Xlsx_writer {
+	return &Xlsx_writer{
+		sheet: "Sheet1",
+	}
+}
+
+//	Sheet name
+func (w *Xlsx_writer) Sheet(name string) *Xlsx_writer {
+	w.sheet = name
+	return w
+}
+
+//	Column types (e.g. from Table.Infer) so numbers, booleans and dates are written as typed cells
+func (w *Xlsx_writer) Types(types []Column_type) *Xlsx_writer {
+	w.types = types
+	return w
+}
+
+//	Excel number formats per column (e.g. "#,##0.00" or "dd-mm-yyyy")
+func (w *Xlsx_writer) Formats(formats []string) *Xlsx_writer {
+	w.formats = formats
+	return w
+}
+
+//	Write table to file
+func (w *Xlsx_writer) File(file string, t Table) error {
+	var buf bytes.Buffer
+	if err := w.Write(&buf, t); err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, buf.Bytes(), futil.CHMOD_RW_OWNER); err != nil {
+		return new_error(ErrWrite, "Unable to write file", err)
+	}
+	return nil
+}
+
+//	Write table as workbook with a bold and frozen header row
+func (w *Xlsx_writer) Write(dst io.Writer, t Table) error {
+	if w.sheet == "" || utf8.RuneCountInString(w.sheet) > 31 || strings.ContainsAny(w.sheet, `[]:*?/\`) {
+		return new_error(ErrOptions, "Invalid sheet name: "+w.sheet, nil)
+	}
+	
+	styles := &xlsx_style_sheet{
+		xfs: map[string]int{},
+	}
+	sheet := w.sheet_xml(t, styles)
+	
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	files := []struct{
+		name	string
+		content	string
+	}{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`+xml_escape(w.sheet)+`" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+		{"xl/worksheets/sheet1.xml", sheet},
+		{"xl/styles.xml", styles.xml()},
+	}
+	for _, f := range files {
+		fw, err := z.Create(f.name)
+		if err != nil {
+			return new_error(ErrWrite, "Unable to write XLSX", err)
+		}
+		if _, err := io.WriteString(fw, f.content); err != nil {
+			return new_error(ErrWrite, "Unable to write XLSX", err)
+		}
+	}
+	if err := z.Close(); err != nil {
+		return new_error(ErrWrite, "Unable to write XLSX", err)
+	}
+	
+	if _, err := dst.Write(buf.Bytes()); err != nil {
+		return new_error(ErrWrite, "Unable to write XLSX", err)
+	}
+	return nil
+}
+
+func (w *Xlsx_writer) sheet_xml(t Table, styles *xlsx_style_sheet) string {
+	cols := len(t.Header)
+	for _, row := range t.Rows {
+		cols = max(cols, len(row.Row))
+	}
+	widths := make([]int, cols)
+	
+	var rows strings.Builder
+	r := 0
+	if len(t.Header) != 0 {
+		r++
+		rows.WriteString(`<row r="1">`)
+		for c, value := range t.Header {
+			widths[c] = max(widths[c], utf8.RuneCountInString(value))
+			rows.WriteString(xlsx_text_cell(xlsx_ref(c, r), value, styles.xf("", true)))
+		}
+		rows.WriteString(`</row>`)
+	}
+	for _, row := range t.Rows {
+		r++
+		fmt.Fprintf(&rows, `<row r="%d">`, r)
+		for c, value := range row.Row {
+			if value == "" {
+				continue
+			}
+			widths[c] = max(widths[c], utf8.RuneCountInString(value))
+			rows.WriteString(w.cell(xlsx_ref(c, r), c, value, styles))
+		}
+		rows.WriteString(`</row>`)
+	}
+	
+	var s strings.Builder
+	s.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(t.Header) != 0 {
+		s.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	if cols != 0 {
+		s.WriteString(`<cols>`)
+		for c, width := range widths {
+			width = min(max(width + 2, xlsx_min_width), xlsx_max_width)
+			fmt.Fprintf(&s, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, c + 1, c + 1, width)
+		}
+		s.WriteString(`</cols>`)
+	}
+	s.WriteString(`<sheetData>`)
+	s.WriteString(rows.String())
+	s.WriteString(`</sheetData></worksheet>`)
+	return s.String()
+}
+
+//	Write cell typed by the column type (values not matching the type are written as text)
+func (w *Xlsx_writer) cell(ref string, c int, value string, styles *xlsx_style_sheet) string {
+	var (
+		ct		Column_type
+		format	string
+	)
+	if c < len(w.types) {
+		ct = w.types[c]
+	}
+	if c < len(w.formats) {
+		format = w.formats[c]
+	}
+	
+	switch ct.Type {
+	case TYPE_INT, TYPE_DECIMAL:
+		n, ok := parse_number(value, ct.Decimal)
+		if !ok || len(strings.Trim(strings.Replace(n.value, ".", "", 1), "-0")) > xlsx_max_digits {
+			break
+		}
+		v := n.value
+		if n.percent {
+			f, _ := strconv.ParseFloat(n.value, 64)
+			v = excel_number(f / 100)
+			if format == "" {
+				format = xlsx_percent_format
+			}
+		}
+		return fmt.Sprintf(`<c r="%s"%s><v>%s</v></c>`, ref, xlsx_style_attr(styles.xf(format, false)), v)
+	case TYPE_BOOL:
+		b, ok := parse_bool(value)
+		if !ok {
+			break
+		}
+		v := "0"
+		if b {
+			v = "1"
+		}
+		return fmt.Sprintf(`<c r="%s" t="b"%s><v>%s</v></c>`, ref, xlsx_style_attr(styles.xf(format, false)), v)
+	case TYPE_DATE, TYPE_DATETIME:
+		layout := ct.Layout
+		if layout == "" {
+			date, tm, ok := date_layout(value)
+			if !ok {
+				break
+			}
+			layout = date + tm
+		}
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			break
+		}
+		if format == "" {
+			format = xlsx_date_format
+			if ct.Type == TYPE_DATETIME {
+				format = xlsx_datetime_format
+			}
+		}
+		return fmt.Sprintf(`<c r="%s"%s><v>%s</v></c>`, ref, xlsx_style_attr(styles.xf(format, false)), excel_number(excel_serial(t)))
+	}
+	return xlsx_text_cell(ref, value, styles.xf(format, false))
+}
+
+//	Get style index for number format and font
+func (s *xlsx_style_sheet) xf(format string, bold bool) int {
+	if format == "" && !bold {
+		return 0
+	}
+	key := fmt.Sprintf("%t:%s", bold, format)
+	if i, ok := s.xfs[key]; ok {
+		return i
+	}
+	s.formats = append(s.formats, key)
+	i := len(s.formats)
+	s.xfs[key] = i
+	return i
+}
+
+func (s *xlsx_style_sheet) xml() string {
+	var (
+		formats	[]string
+		ids		= map[string]int{}
+		xfs		strings.Builder
+	)
+	xfs.WriteString(`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>`)
+	for _, key := range s.formats {
+		bold, format, _ := strings.Cut(key, ":")
+		id := 0
+		if format != "" {
+			var ok bool
+			if id, ok = ids[format]; !ok {
+				id = xlsx_format_offset + len(formats)
+				ids[format] = id
+				formats = append(formats, format)
+			}
+		}
+		font := 0
+		if bold == "true" {
+			font = 1
+		}
+		fmt.Fprintf(&xfs, `<xf numFmtId="%d" fontId="%d" fillId="0" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1"/>`, id, font)
+	}
+	
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(formats) != 0 {
+		fmt.Fprintf(&b, `<numFmts count="%d">`, len(formats))
+		for i, format := range formats {
+			fmt.Fprintf(&b, `<numFmt numFmtId="%d" formatCode="%s"/>`, xlsx_format_offset + i, xml_escape(format))
+		}
+		b.WriteString(`</numFmts>`)
+	}
+	b.WriteString(`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>`)
+	b.WriteString(`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>`)
+	b.WriteString(`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>`)
+	b.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+	fmt.Fprintf(&b, `<cellXfs count="%d">%s</cellXfs>`, len(s.formats) + 1, xfs.String())
+	b.WriteString(`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles></styleSheet>`)
+	return b.String()
+}
+
+func xlsx_text_cell(ref, value string, style int) string {
+	return fmt.Sprintf(`<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`, ref, xlsx_style_attr(style), xml_escape(value))
+}
+
+func xlsx_style_attr(style int) string {
+	if style == 0 {
+		return ""
+	}
+	return fmt.Sprintf(` s="%d"`, style)
+}
+
+//	Get cell reference from column index and row number (0, 1 => A1)
+func xlsx_ref(col, row int) string {
+	var name []byte
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = append([]byte{byte('A' + (col - 1) % 26)}, name...)
+	}
+	return string(name)+strconv.Itoa(row)
+}
+
+//	Convert time to Excel serial date
+func excel_serial(t time.Time) float64 {
+	d := t.Sub(excel_epoch).Hours() / 24
+	//	Excel treats 1900 as a leap year
+	if d < 61 {
+		d--
+	}
+	return math.Round(d * 86400) / 86400
+}
+
+func xml_escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
\ No newline at end of file
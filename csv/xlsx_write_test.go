@@ -0,0 +1,73 @@
+package csv
+
+import (
+	"io"
+	"bytes"
+	"strings"
+	"testing"
+	"archive/zip"
+)
+
+func Test_xlsx_write(t *testing.T){
+	input := `Name;Amount;Rate;Date;Paid;Account
+Anna;1.234,50;12,5 %;31-01-2024;ja;00123
+Bo;-12;5 %;01-02-2024;nej;1234567890123456789`
+	table, err := NewReader("").Bytes([]byte(input), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	
+	var buf bytes.Buffer
+	err = NewXlsx_writer().Sheet("Data").Types(table.Infer()).Formats([]string{"", "#,##0.00"}).Write(&buf, table)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	
+	out, err := NewReader("").Bytes(buf.Bytes(), MIME_XLXS)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	verify_table(t, out, "Name,Amount,Rate,Date,Paid,Account", "Anna,1234.5,0.125,2024-01-31,TRUE,00123\nBo,-12,0.05,2024-02-01,FALSE,1234567890123456789")
+	
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	files := map[string]string{}
+	for _, f := range z.File {
+		r, _ := f.Open()
+		b, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(b)
+	}
+	for name, want := range map[string]string{
+		"xl/worksheets/sheet1.xml":	`state="frozen"`,
+		"xl/styles.xml":			`<b/>`,
+		"xl/workbook.xml":			`name="Data"`,
+	} {
+		if !strings.Contains(files[name], want) {
+			t.Fatalf("Want %s in %s", want, name)
+		}
+	}
+	if !strings.Contains(files["xl/styles.xml"], `formatCode="#,##0.00"`) {
+		t.Fatal("Want custom number format")
+	}
+	
+	if err := NewXlsx_writer().Sheet("a/b").Write(&buf, table); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
+
+func Test_xlsx_ref(t *testing.T){
+	for col, want := range map[int]string{0: "A1", 25: "Z1", 26: "AA1", 701: "ZZ1", 702: "AAA1"} {
+		if got := xlsx_ref(col, 1); got != want {
+			t.Fatalf("Column %d want %s, got %s", col, want, got)
+		}
+		if got := xlsx_col(want); got != col {
+			t.Fatalf("Ref %s want column %d, got %d", want, col, got)
+		}
+	}
+	if got := xlsx_col("ZZZZZZZZZZZZZZ1"); got != sheet_cols {
+		t.Fatalf("Long ref want column %d, got %d", sheet_cols, got)
+	}
+}
\ No newline at end of file